@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// scrubAccounts assumes the -assumeRole role in each selected account
+// and scrubs (or plans) them one after the other. A failure in one account
+// doesn't stop the others, and a summary is logged at the end.
+func scrubAccounts(ctx context.Context, c *cfg, prot *protection, guard *guardrails, base aws.Config, limits resource.RateLimits) error {
+	ac := regionConfig(base, base.Region, limits)
+	_, partition, err := callerIdentity(ctx, ac)
+	if err != nil {
+		return err
+	}
+
+	accounts := c.accounts
+	if c.ou != "" {
+		accounts, err = ouAccounts(ctx, ac, c.ou)
+		if err != nil {
+			return err
+		}
+		if len(accounts) == 0 {
+			return fmt.Errorf("no active accounts found in %s", c.ou)
+		}
+	}
+	accounts = slices.Compact(slices.Sorted(slices.Values(accounts)))
+
+	var summary []string
+	failed := 0
+	for _, account := range accounts {
+		n, err := scrubAccount(ctx, c, prot, guard, assumeRoleConfig(base, partition, account, c.assumeRole), limits, account)
+		if err != nil {
+			log.Printf("error: %s", err)
+			summary = append(summary, account+": failed (see above)")
+			failed++
+			continue
+		}
+
+		var done string
+		switch {
+		case c.command == commandPlan:
+			done = "planned for deletion"
+		case c.dryRun:
+			done = "would be deleted"
+		default:
+			done = "deleted"
+		}
+		summary = append(summary, fmt.Sprintf("%s: %d resources %s", account, n, done))
+	}
+
+	log.Printf("summary of %d accounts:", len(accounts))
+	for _, line := range summary {
+		log.Printf("  %s", line)
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed in %d of %d accounts", failed, len(accounts))
+	}
+	return nil
+}
+
+// scrubAccount scrubs (or plans) one account. Errors are labelled with
+// the account.
+func scrubAccount(ctx context.Context, c *cfg, prot *protection, guard *guardrails, ac aws.Config, limits resource.RateLimits, account string) (int, error) {
+	pl, err := newPlanner(ctx, c, prot, guard, ac, limits, account)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", account, err)
+	}
+	pl.labelAccount = true
+
+	plans, err := pl.regionPlans(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", account, err)
+	}
+	return run(ctx, c, prot, plans)
+}
+
+// assumeRoleConfig returns a copy of an AWS config which uses a role in
+// another account.
+func assumeRoleConfig(base aws.Config, partition, account, role string) aws.Config {
+	roleARN := arn.ARN{
+		Partition: partition,
+		Service:   "iam",
+		AccountID: account,
+		Resource:  "role/" + role,
+	}
+
+	ac := base.Copy()
+	ac.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(base), roleARN.String(), func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "aws-project-scrub"
+	}))
+	return ac
+}
+
+// ouAccounts lists the active accounts in an organizational unit, and in
+// its child units.
+func ouAccounts(ctx context.Context, ac aws.Config, ou string) ([]string, error) {
+	c := organizations.NewFromConfig(ac)
+
+	var accounts []string
+	parents := []string{ou}
+	for len(parents) > 0 {
+		parent := parents[0]
+		parents = parents[1:]
+
+		ap := organizations.NewListAccountsForParentPaginator(c, &organizations.ListAccountsForParentInput{
+			ParentId: &parent,
+		})
+		for ap.HasMorePages() {
+			page, err := ap.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("listing accounts in %s: %s", parent, err)
+			}
+			for _, a := range page.Accounts {
+				if a.Id == nil || a.Status != types.AccountStatusActive {
+					continue
+				}
+				accounts = append(accounts, *a.Id)
+			}
+		}
+
+		op := organizations.NewListOrganizationalUnitsForParentPaginator(c, &organizations.ListOrganizationalUnitsForParentInput{
+			ParentId: &parent,
+		})
+		for op.HasMorePages() {
+			page, err := op.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("listing organizational units in %s: %s", parent, err)
+			}
+			for _, u := range page.OrganizationalUnits {
+				if u.Id != nil {
+					parents = append(parents, *u.Id)
+				}
+			}
+		}
+	}
+
+	return accounts, nil
+}
@@ -3,36 +3,298 @@ package main
 import (
 	"errors"
 	"flag"
-	"reflect"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/filter"
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// commands. With no command we discover and delete resources in
+// one go.
+const (
+	commandScrub = "scrub"
+	commandPlan  = "plan"
+	commandApply = "apply"
+	commandGraph = "graph"
 )
 
 type cfg struct {
+	command string
+
+	// config file, and the profile in it to use
+	configFile string
+	profile    string
+
 	region   string
 	account  string
 	tagKey   string
 	tagValue string
 	dryRun   bool
+
+	// regions to scrub, instead of a single region ("all" for every
+	// enabled region)
+	regions []string
+
+	// region to handle global resources in, instead of the partition's,
+	// and whether to leave them out (or do only them)
+	globalRegion string
+	skipGlobal   bool
+	onlyGlobal   bool
+
+	// role to assume into each of a list of accounts (or the accounts
+	// in an organizational unit), instead of scrubbing our own account
+	assumeRole string
+	accounts   []string
+	ou         string
+
+	// tag-filter expression, combined with tagKey/tagValue
+	filterExpr string
+	filter     *filter.Expr
+
+	// resource-name glob pattern, combined with the filter
+	namePattern string
+
+	// protection tag (KEY=VALUE) and file of resources
+	// never to delete
+	protectTag  string
+	protectFile string
+	protectIDs  []string
+
+	// accounts we refuse to run against, unless overridden (see
+	// guardrails)
+	denyAccounts       []string
+	allowAlias         []string
+	accountFilter      string
+	accountTags        string
+	overrideGuardrails []string
+
+	// what to do with dependent resources which belong
+	// to another project
+	foreign string
+
+	// find root resources with the resource groups tagging API
+	taggingAPI bool
+
+	// CloudFormation stack or Terraform state file whose resources are
+	// the root resources, instead of searching by tag
+	stack   string
+	tfstate string
+
+	// resource-type patterns to include or exclude
+	include patternList
+	exclude patternList
+
+	// only select root resources created before/after these ages
+	olderThan         time.Duration
+	newerThan         time.Duration
+	includeUnknownAge bool
+
+	// credentials: shared-config profile, and a role to assume with it
+	// (or with a web identity token)
+	awsProfile           string
+	roleARN              string
+	roleSessionName      string
+	roleDuration         time.Duration
+	externalID           string
+	mfaSerial            string
+	webIdentityTokenFile string
+
+	rateLimits serviceLimits
+
+	retryTimeout time.Duration
+	keepGoing    bool
+
+	concurrency     int
+	typeConcurrency typeLimits
+
+	// journal file to write, and whether we're resuming from it
+	journal string
+	resume  bool
+
+	discoveryConcurrency int
+
+	// plan output-file ('plan' command)
+	planOut string
+	// plan input-file ('apply' command)
+	planFile string
+	// output format ('graph' command)
+	graphFormat string
 }
 
 func getFlags() (*cfg, error) {
 	var c cfg
-	flag.StringVar(&c.region, "region", "", "AWS region")
-	flag.StringVar(&c.account, "account", "", "AWS account-id")
-	flag.StringVar(&c.tagKey, "tagKey", "", "resource-tag key to search for")
-	flag.StringVar(&c.tagValue, "tagValue", "", "resource-tag value to search for")
-	flag.BoolVar(&c.dryRun, "dryRun", true, "dry-run (do not delete resources)")
-	flag.Parse()
+
+	args := os.Args[1:]
+	c.command = commandScrub
+	if len(args) > 0 {
+		switch args[0] {
+		case commandPlan, commandApply, commandGraph:
+			c.command = args[0]
+			args = args[1:]
+		}
+	}
+
+	fs := newFlagSet(&c)
+	fs.StringVar(&c.configFile, "config", "", "YAML or JSON config `file` with named profiles of flag-settings (see -profile)")
+	fs.StringVar(&c.profile, "profile", "", "`name` of the profile in the -config file to use; flags on the command line override its settings")
+	fs.Parse(args)
+
+	if (c.configFile == "") != (c.profile == "") {
+		return nil, errors.New("flags -config and -profile must be used together")
+	}
+	if c.configFile != "" {
+		err := applyConfigFile(fs, c.configFile, c.profile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var el []error
 
-	// lol
-	v := reflect.ValueOf(c)
-	for i := range v.NumField() {
-		f := v.Field(i)
-		sf := v.Type().Field(i)
+	switch {
+	case c.command == commandApply && fs.NArg() == 1:
+		c.planFile = fs.Arg(0)
+	case c.command == commandApply:
+		el = append(el, fmt.Errorf("usage: %s [flags] plan.json", fs.Name()))
+	case fs.NArg() != 0:
+		el = append(el, fmt.Errorf("unexpected arguments: %q", fs.Args()))
+	}
+
+	if c.journal != "" && c.dryRun {
+		el = append(el, errors.New("flags -journal and -resume require -dryRun=false"))
+	}
+
+	if c.skipGlobal && c.onlyGlobal {
+		el = append(el, errors.New("flags -skipGlobal and -onlyGlobal can't be used together"))
+	}
+
+	if c.roleARN != "" {
+		if _, err := arn.Parse(c.roleARN); err != nil {
+			el = append(el, fmt.Errorf("flag -roleArn: %s", err))
+		}
+		if c.webIdentityTokenFile != "" && (c.externalID != "" || c.mfaSerial != "") {
+			el = append(el, errors.New("flags -externalId and -mfaSerial can't be used with -webIdentityTokenFile"))
+		}
+	} else {
+		for _, name := range []string{"roleSessionName", "roleDuration", "externalId", "mfaSerial", "webIdentityTokenFile"} {
+			if isFlagSet(fs, name) {
+				el = append(el, fmt.Errorf("flag -%s requires -roleArn", name))
+			}
+		}
+	}
+	if c.roleDuration < 15*time.Minute {
+		el = append(el, errors.New("flag -roleDuration must be at least 15m"))
+	}
+
+	for _, name := range []string{"region", "globalRegion"} {
+		if v := fs.Lookup(name); v != nil && v.Value.String() != "" && !resource.IsRegionName(v.Value.String()) {
+			el = append(el, fmt.Errorf("flag -%s: unknown region %q", name, v.Value.String()))
+		}
+	}
+	for _, r := range c.regions {
+		if r != "all" && !resource.IsRegionName(r) {
+			el = append(el, fmt.Errorf("flag -regions: unknown region %q", r))
+		}
+	}
+
+	if c.protectTag != "" && !strings.Contains(c.protectTag, "=") {
+		el = append(el, fmt.Errorf("flag -protectTag: expected KEY=VALUE, got %q", c.protectTag))
+	}
+
+	if c.olderThan < 0 || c.newerThan < 0 {
+		el = append(el, errors.New("flags -olderThan and -newerThan must not be negative"))
+	}
+	if c.olderThan > 0 && c.newerThan > 0 && c.newerThan <= c.olderThan {
+		el = append(el, errors.New("flag -newerThan must be greater than -olderThan"))
+	}
+	if c.taggingAPI && (c.olderThan > 0 || c.newerThan > 0) {
+		// every root resource would have an unknown age
+		el = append(el, errors.New("flag -taggingAPI can't be used with -olderThan or -newerThan (the tagging API doesn't report creation-times)"))
+	}
+
+	switch c.foreign {
+	case "", foreignAllow, foreignSkip, foreignStop:
+	default:
+		el = append(el, fmt.Errorf("flag -foreign: unknown value %q (expected allow, skip or stop)", c.foreign))
+	}
+
+	switch c.graphFormat {
+	case "", "dot", "mermaid":
+	default:
+		el = append(el, fmt.Errorf("unknown graph format %q", c.graphFormat))
+	}
+
+	// flags without defaults which must be passed in (if the
+	// command has them)
+	for _, name := range []string{"region", "account"} {
+		f := fs.Lookup(name)
+		if name == "region" && len(c.regions) > 0 {
+			continue
+		}
+		if name == "account" && c.assumeRole != "" {
+			continue
+		}
+		if f != nil && f.Value.String() == "" {
+			el = append(el, errors.New("flag -"+name+" is required"))
+		}
+	}
+
+	if len(c.regions) > 0 {
+		if c.region != "" {
+			el = append(el, errors.New("flags -region and -regions can't be used together"))
+		}
+		if len(c.regions) > 1 && slices.Contains(c.regions, "all") {
+			el = append(el, errors.New("flag -regions: 'all' can't be combined with other regions"))
+		}
+		// these are tied to a single region
+		for _, name := range []string{"journal", "resume", "out", "stack", "tfstate"} {
+			if isFlagSet(fs, name) {
+				el = append(el, fmt.Errorf("flag -%s can't be used with -regions", name))
+			}
+		}
+	}
+
+	if c.command != commandApply {
+		if err := c.buildFilter(); err != nil {
+			el = append(el, err)
+		}
+	}
+	if c.assumeRole != "" {
+		if c.account != "" {
+			el = append(el, errors.New("flag -account can't be used with -assumeRole (use -accounts)"))
+		}
+		if (len(c.accounts) == 0) == (c.ou == "") {
+			el = append(el, errors.New("flag -assumeRole requires one of -accounts or -ou"))
+		}
+		// these are tied to a single account
+		for _, name := range []string{"journal", "resume", "out", "stack", "tfstate"} {
+			if isFlagSet(fs, name) {
+				el = append(el, fmt.Errorf("flag -%s can't be used with -assumeRole", name))
+			}
+		}
+	} else if len(c.accounts) != 0 || c.ou != "" {
+		el = append(el, errors.New("flags -accounts and -ou require -assumeRole"))
+	}
 
-		if sf.Type.Kind() == reflect.String && f.IsZero() {
-			el = append(el, errors.New("flag -"+sf.Name+" is required"))
+	if c.stack != "" && c.tfstate != "" {
+		el = append(el, errors.New("flags -stack and -tfstate can't be used together"))
+	}
+	if sel := c.rootSelector(); sel != "" {
+		// stack and state-file resources are selected as-is
+		for _, name := range []string{"tagKey", "tagValue", "filter", "namePattern", "olderThan", "newerThan", "taggingAPI"} {
+			if isFlagSet(fs, name) {
+				el = append(el, fmt.Errorf("flag -%s can't be used with -%s", name, sel))
+			}
 		}
 	}
 
@@ -42,3 +304,236 @@ func getFlags() (*cfg, error) {
 
 	return &c, nil
 }
+
+// newFlagSet defines the flags of a command (c.command) on c.
+func newFlagSet(c *cfg) *flag.FlagSet {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0])+" "+c.command, flag.ExitOnError)
+	fs.StringVar(&c.region, "region", "", "AWS region")
+	fs.StringVar(&c.account, "account", "", "AWS account-id")
+	fs.StringVar(&c.globalRegion, "globalRegion", "", "`region` to delete global resources (IAM, Route53) from, instead of the partition's global region (such as us-east-1)")
+	fs.BoolVar(&c.skipGlobal, "skipGlobal", false, "don't delete global resources, even in the global region")
+	fs.BoolVar(&c.onlyGlobal, "onlyGlobal", false, "only delete global resources (in the global region, whatever the -region)")
+	fs.StringVar(&c.protectTag, "protectTag", "scrub:protect=true", "never delete resources with this tag, as `KEY=VALUE` (empty disables)")
+	fs.Func("protectId", "resources never to delete, in the same forms as the lines of a -protect file (may be repeated or comma-separated)", listFlag(&c.protectIDs))
+	fs.StringVar(&c.protectFile, "protect", "", "`file` listing resources never to delete, one per line: ARNs, resource-IDs or resource-type patterns")
+	fs.Func("denyAccounts", "comma-separated account-`ids` never to run against, whatever the other flags say", listFlag(&c.denyAccounts))
+	fs.Func("allowAlias", "only run against accounts whose IAM account alias matches one of these comma-separated glob `patterns`, for example '*-sandbox'", listFlag(&c.allowAlias))
+	fs.StringVar(&c.accountFilter, "accountFilter", "", "only run against accounts whose AWS Organizations tags match this tag-filter `expression`, for example 'environment=sandbox'")
+	fs.StringVar(&c.accountTags, "accountTags", "", "`file` of account tags for -accountFilter, one account per line as ACCOUNT-ID KEY=VALUE..., instead of looking them up in AWS Organizations")
+	fs.Func("iUnderstandThisIsNotASandboxAccount", "run against this account-`id` even though -allowAlias or -accountFilter rejects it (not -denyAccounts; command line only)", listFlag(&c.overrideGuardrails))
+	fs.StringVar(&c.awsProfile, "awsProfile", "", "AWS shared-config `profile` to use (instead of AWS_PROFILE)")
+	fs.StringVar(&c.roleARN, "roleArn", "", "`ARN` of an IAM role to assume before doing anything else")
+	fs.StringVar(&c.roleSessionName, "roleSessionName", "aws-project-scrub", "session `name` for -roleArn")
+	fs.DurationVar(&c.roleDuration, "roleDuration", time.Hour, "how long -roleArn credentials last before they are renewed (with -mfaSerial, renewing asks for another code)")
+	fs.StringVar(&c.externalID, "externalId", "", "external `id` to pass when assuming -roleArn")
+	fs.StringVar(&c.mfaSerial, "mfaSerial", "", "`serial` number (or ARN) of the MFA device to use when assuming -roleArn; the code is asked for on the terminal")
+	fs.StringVar(&c.webIdentityTokenFile, "webIdentityTokenFile", "", "`file` holding a web identity (OIDC) token to assume -roleArn with, as in CI")
+	fs.Var(&c.rateLimits, "rateLimit", "override the client-side request rate-limit for an AWS service, as `SERVICE=RPS` (for example EC2=10 or route53=2; 0 disables; may be repeated or comma-separated)")
+	if c.command != commandApply {
+		fs.StringVar(&c.tagKey, "tagKey", "", "resource-tag key to search for")
+		fs.StringVar(&c.tagValue, "tagValue", "", "resource-tag value to search for")
+		fs.StringVar(&c.filterExpr, "filter", "", "resource-tag filter `expression`, for example 'project=foo AND env!=prod' (combined with -tagKey and -tagValue)")
+		fs.StringVar(&c.namePattern, "namePattern", "", "only select resources whose names match this glob `pattern`, for example 'myproj-*' (log groups, IAM roles and policies, SQS queues, event rules, EKS clusters and hosted zones; combined with the tag filter)")
+		fs.IntVar(&c.discoveryConcurrency, "discoveryConcurrency", 10, "maximum number of concurrent resource-discovery lookups")
+		fs.StringVar(&c.stack, "stack", "", "CloudFormation stack `name` (or ID) whose resources to delete, instead of searching by tag")
+		fs.StringVar(&c.tfstate, "tfstate", "", "Terraform state `file` (version 4) whose resources to delete, instead of searching by tag")
+		fs.BoolVar(&c.taggingAPI, "taggingAPI", false, "find root resources with the resource groups tagging API, for resource-types which support it")
+		fs.StringVar(&c.foreign, "foreign", foreignAllow, "what to do with dependent resources tagged for another project (a different value for a tag the filter requires): allow, skip, or stop")
+		fs.Var(&c.include, "include", "only delete resources whose type matches one of these `patterns` (for example AWS::EKS::*; may be repeated or comma-separated)")
+		fs.Var(&c.exclude, "exclude", "don't delete resources whose type matches one of these `patterns` (may be repeated or comma-separated)")
+		fs.DurationVar(&c.olderThan, "olderThan", 0, "only select resources created at least this long ago")
+		fs.DurationVar(&c.newerThan, "newerThan", 0, "only select resources created less than this long ago")
+		fs.BoolVar(&c.includeUnknownAge, "includeUnknownAge", false, "with -olderThan or -newerThan, also select resources whose creation-time is unknown")
+	}
+	if c.command == commandScrub {
+		fs.BoolVar(&c.dryRun, "dryRun", true, "dry-run (do not delete resources)")
+	}
+	if c.command == commandScrub || c.command == commandPlan {
+		fs.Func("regions", "comma-separated `regions` to scrub instead of -region, or 'all' for every enabled region (global resources are handled once, after the regional ones)", listFlag(&c.regions))
+		fs.StringVar(&c.assumeRole, "assumeRole", "", "`role` name to assume in each of the -accounts (or the accounts in the -ou), instead of scrubbing the current account")
+		fs.Func("accounts", "comma-separated account-`ids` to scrub with -assumeRole", listFlag(&c.accounts))
+		fs.StringVar(&c.ou, "ou", "", "organizational unit `id` whose accounts (including those in child units) to scrub with -assumeRole")
+	}
+	if c.command == commandScrub || c.command == commandApply {
+		fs.BoolVar(&c.keepGoing, "keepGoing", false, "keep deleting unrelated resources after a deletion fails")
+		fs.IntVar(&c.concurrency, "concurrency", 20, "maximum number of concurrent deletions")
+		fs.Var(&c.typeConcurrency, "typeConcurrency", "maximum number of concurrent deletions for a resource-type, as `TYPE=N` (may be repeated or comma-separated)")
+		fs.DurationVar(&c.retryTimeout, "retryTimeout", 10*time.Minute, "how long to keep retrying deletions which fail because a resource is still in use (0 disables retries)")
+		fs.StringVar(&c.journal, "journal", "", "file to record deletion progress to (JSON lines)")
+		fs.Func("resume", "resume from a journal file, skipping resources it records as deleted (keeps appending to the journal)", func(s string) error {
+			c.journal = s
+			c.resume = true
+			return nil
+		})
+	}
+	if c.command == commandPlan {
+		fs.StringVar(&c.planOut, "out", "", "file to write the plan to")
+	}
+	if c.command == commandGraph {
+		fs.StringVar(&c.graphFormat, "format", "dot", "graph format (dot or mermaid)")
+	}
+	return fs
+}
+
+// listFlag returns a flag-function appending comma-separated values
+// to a list.
+func listFlag(l *[]string) func(s string) error {
+	return func(s string) error {
+		for _, v := range strings.Split(s, ",") {
+			if v == "" {
+				return fmt.Errorf("empty value in %q", s)
+			}
+			*l = append(*l, v)
+		}
+		return nil
+	}
+}
+
+// rootSelector returns the name of the flag which selects root resources
+// directly (-stack or -tfstate), or "" if they're found by tag.
+func (c *cfg) rootSelector() string {
+	switch {
+	case c.stack != "":
+		return "stack"
+	case c.tfstate != "":
+		return "tfstate"
+	}
+	return ""
+}
+
+// isFlagSet reports if a flag was passed on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// buildFilter combines the -filter expression with the -tagKey and
+// -tagValue flags.
+func (c *cfg) buildFilter() error {
+	if (c.tagKey == "") != (c.tagValue == "") {
+		return errors.New("flags -tagKey and -tagValue must be used together")
+	}
+	if c.filterExpr == "" && c.tagKey == "" {
+		if c.namePattern != "" || c.rootSelector() != "" {
+			return nil
+		}
+		return errors.New("flag -filter, flags -tagKey and -tagValue, -namePattern, -stack or -tfstate are required")
+	}
+
+	var parsed *filter.Expr
+	if c.filterExpr != "" {
+		var err error
+		parsed, err = filter.Parse(c.filterExpr)
+		if err != nil {
+			return fmt.Errorf("parsing -filter: %s", err)
+		}
+	}
+	var tag *filter.Expr
+	if c.tagKey != "" {
+		tag = filter.Equals(c.tagKey, c.tagValue)
+	}
+	c.filter = filter.And(tag, parsed)
+	return nil
+}
+
+// patternList is a flag-value holding resource-type glob patterns
+// (PATTERN,PATTERN).
+type patternList []string
+
+func (l *patternList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *patternList) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		if _, err := path.Match(part, ""); err != nil || part == "" {
+			return fmt.Errorf("invalid pattern %q", part)
+		}
+		*l = append(*l, part)
+	}
+	return nil
+}
+
+// matches reports if any pattern in the list matches a resource-type.
+func (l patternList) matches(typ string) bool {
+	for _, p := range l {
+		if ok, _ := path.Match(p, typ); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// typeLimits is a flag-value holding per-resource-type limits
+// (TYPE=N,TYPE=N).
+type typeLimits map[string]int
+
+func (l *typeLimits) String() string {
+	if l == nil || *l == nil {
+		return ""
+	}
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(*l)) {
+		parts = append(parts, k+"="+strconv.Itoa((*l)[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *typeLimits) Set(s string) error {
+	if *l == nil {
+		*l = typeLimits{}
+	}
+	for _, part := range strings.Split(s, ",") {
+		typ, n, ok := strings.Cut(part, "=")
+		if !ok || typ == "" {
+			return fmt.Errorf("expected TYPE=N, got %q", part)
+		}
+		limit, err := strconv.Atoi(n)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid limit for %q: %q", typ, n)
+		}
+		(*l)[typ] = limit
+	}
+	return nil
+}
+
+// serviceLimits is a flag-value holding per-service request rates
+// (SERVICE=RPS,SERVICE=RPS).
+type serviceLimits map[string]float64
+
+func (l *serviceLimits) String() string {
+	if l == nil || *l == nil {
+		return ""
+	}
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(*l)) {
+		parts = append(parts, k+"="+strconv.FormatFloat((*l)[k], 'g', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *serviceLimits) Set(s string) error {
+	if *l == nil {
+		*l = serviceLimits{}
+	}
+	for _, part := range strings.Split(s, ",") {
+		svc, n, ok := strings.Cut(part, "=")
+		if !ok || svc == "" {
+			return fmt.Errorf("expected SERVICE=RPS, got %q", part)
+		}
+		rps, err := strconv.ParseFloat(n, 64)
+		if err != nil || rps < 0 {
+			return fmt.Errorf("invalid rate for %q: %q", svc, n)
+		}
+		(*l)[svc] = rps
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A config file holds named profiles of flag-settings, in YAML (or JSON):
+//
+//	profiles:
+//	  myproj:
+//	    region: us-east-2
+//	    account: "123456789012"
+//	    filter: project=myproj AND env!=prod
+//	    exclude: [AWS::Route53::HostedZone]
+//	    protectId: [vpc-0123456789abcdef0]
+//	    typeConcurrency: {AWS::EKS::Nodegroup: 2}
+//	    retryTimeout: 20m
+//
+// Settings are named after flags and take the same values. Lists are the
+// same as comma-separated values, and maps the same as KEY=VALUE lists.
+// Settings for flags of other commands are ignored, so one profile can be
+// used for 'plan' and 'apply'.
+
+// commandLineOnly are the flags a config file can't set: those choosing
+// the config file, and the guardrail override, which has to be deliberate
+// every time.
+var commandLineOnly = map[string]bool{
+	"config":                              true,
+	"profile":                             true,
+	"iUnderstandThisIsNotASandboxAccount": true,
+}
+
+// applyConfigFile sets the flags in a profile of a config file, except
+// for those already set on the command line.
+func applyConfigFile(fs *flag.FlagSet, file, profile string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading config file: %s", err)
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %s", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return fmt.Errorf("%s: no profiles", file)
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: expected a mapping with the key 'profiles'", file, root.Line)
+	}
+	var profiles *yaml.Node
+	for k, v := range mappingPairs(root) {
+		if k.Value != "profiles" {
+			return fmt.Errorf("%s:%d: unknown key %q (expected 'profiles')", file, k.Line, k.Value)
+		}
+		profiles = v
+	}
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected 'profiles' to be a mapping of profile names to settings", file)
+	}
+
+	var settings *yaml.Node
+	var names []string
+	for k, v := range mappingPairs(profiles) {
+		if slices.Contains(names, k.Value) {
+			return fmt.Errorf("%s:%d: profile %q is defined twice", file, k.Line, k.Value)
+		}
+		names = append(names, k.Value)
+		if k.Value == profile {
+			settings = v
+		}
+	}
+	if settings == nil {
+		return fmt.Errorf("%s: no profile %q (profiles are: %s)", file, profile, strings.Join(names, ", "))
+	}
+	if settings.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: expected profile %q to be a mapping of settings", file, settings.Line, profile)
+	}
+
+	onCommandLine := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = true
+	})
+	known := allFlagNames()
+
+	var el []error
+	seen := map[string]bool{}
+	for k, v := range mappingPairs(settings) {
+		name := k.Value
+		pos := fmt.Sprintf("%s:%d", file, k.Line)
+		if seen[name] {
+			el = append(el, fmt.Errorf("%s: setting %q is given twice", pos, name))
+			continue
+		}
+		seen[name] = true
+
+		if commandLineOnly[name] {
+			el = append(el, fmt.Errorf("%s: setting %q can only be given on the command line", pos, name))
+			continue
+		}
+		if !known[name] {
+			err := fmt.Errorf("%s: unknown setting %q", pos, name)
+			if guess := closestName(name, known); guess != "" {
+				err = fmt.Errorf("%s: unknown setting %q (did you mean %q?)", pos, name, guess)
+			}
+			el = append(el, err)
+			continue
+		}
+		if fs.Lookup(name) == nil || onCommandLine[name] {
+			// another command's flag, or overridden
+			continue
+		}
+
+		value, err := settingValue(v)
+		if err != nil {
+			el = append(el, fmt.Errorf("%s: setting %q: %s", pos, name, err))
+			continue
+		}
+		err = fs.Set(name, value)
+		if err != nil {
+			el = append(el, fmt.Errorf("%s: setting %q: invalid value %q: %s", pos, name, value, err))
+		}
+	}
+	if len(el) != 0 {
+		return fmt.Errorf("profile %q in %s:\n%w", profile, file, errors.Join(el...))
+	}
+	return nil
+}
+
+// mappingPairs iterates over the keys and values of a mapping node.
+func mappingPairs(n *yaml.Node) func(yield func(k, v *yaml.Node) bool) {
+	return func(yield func(k, v *yaml.Node) bool) {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if !yield(n.Content[i], resolveAlias(n.Content[i+1])) {
+				return
+			}
+		}
+	}
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// settingValue converts a setting to a flag-value. The scalars' text is
+// used as-is, so account-IDs with leading zeroes don't turn into numbers.
+func settingValue(n *yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return "", errors.New("missing value")
+		}
+		return n.Value, nil
+	case yaml.SequenceNode:
+		var parts []string
+		for _, item := range n.Content {
+			item = resolveAlias(item)
+			if item.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("line %d: expected a list of values", item.Line)
+			}
+			parts = append(parts, item.Value)
+		}
+		return strings.Join(parts, ","), nil
+	case yaml.MappingNode:
+		var parts []string
+		for k, v := range mappingPairs(n) {
+			if v.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("line %d: expected a value for %q", v.Line, k.Value)
+			}
+			parts = append(parts, k.Value+"="+v.Value)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", errors.New("unexpected value")
+}
+
+// allFlagNames returns the names of the flags of every command.
+func allFlagNames() map[string]bool {
+	names := map[string]bool{}
+	for _, command := range []string{commandScrub, commandPlan, commandApply, commandGraph} {
+		newFlagSet(&cfg{command: command}).VisitAll(func(f *flag.Flag) {
+			names[f.Name] = true
+		})
+	}
+	return names
+}
+
+// closestName returns the known name closest to a misspelled one, if
+// there's one close enough.
+func closestName(name string, known map[string]bool) string {
+	best := ""
+	bestDistance := 3
+	for k := range known {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+		if d := editDistance(strings.ToLower(k), strings.ToLower(name)); d < bestDistance || (d == bestDistance && k < best) {
+			best = k
+			bestDistance = d
+		}
+	}
+	if bestDistance > 2 {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
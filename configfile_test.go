@@ -0,0 +1,216 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		command string
+		// command-line flags
+		args []string
+		// error substrings, if the file should be refused
+		wantErr []string
+		check   func(t *testing.T, c *cfg)
+	}{
+		{
+			name: "settings",
+			file: `
+profiles:
+  myproj:
+    region: us-east-2
+    account: "012345678901"
+    filter: project=myproj AND env!=prod
+    exclude: [AWS::Route53::HostedZone, "AWS::IAM::*"]
+    typeConcurrency: {AWS::EKS::Nodegroup: 2}
+    retryTimeout: 20m
+  other:
+    region: eu-west-1
+`,
+			command: commandScrub,
+			check: func(t *testing.T, c *cfg) {
+				if c.region != "us-east-2" || c.account != "012345678901" {
+					t.Errorf("got region %q and account %q", c.region, c.account)
+				}
+				if c.filterExpr != "project=myproj AND env!=prod" {
+					t.Errorf("got filter %q", c.filterExpr)
+				}
+				if want := []string{"AWS::Route53::HostedZone", "AWS::IAM::*"}; !slices.Equal(c.exclude, want) {
+					t.Errorf("got exclude %q, want %q", c.exclude, want)
+				}
+				if want := (typeLimits{"AWS::EKS::Nodegroup": 2}); !maps.Equal(c.typeConcurrency, want) {
+					t.Errorf("got typeConcurrency %v, want %v", c.typeConcurrency, want)
+				}
+				if c.retryTimeout != 20*time.Minute {
+					t.Errorf("got retryTimeout %s", c.retryTimeout)
+				}
+			},
+		},
+		{
+			name: "command line overrides",
+			file: `
+profiles:
+  myproj:
+    region: us-east-2
+    account: "123456789012"
+`,
+			args: []string{"-region", "us-west-2"},
+			check: func(t *testing.T, c *cfg) {
+				if c.region != "us-west-2" || c.account != "123456789012" {
+					t.Errorf("got region %q and account %q", c.region, c.account)
+				}
+			},
+		},
+		{
+			name: "other commands' settings",
+			file: `
+profiles:
+  myproj:
+    region: us-east-2
+    out: plan.json
+    filter: project=myproj
+`,
+			command: commandApply,
+			check: func(t *testing.T, c *cfg) {
+				if c.region != "us-east-2" || c.planOut != "" || c.filterExpr != "" {
+					t.Errorf("got region %q, out %q and filter %q", c.region, c.planOut, c.filterExpr)
+				}
+			},
+		},
+		{
+			name: "unknown settings",
+			file: `
+profiles:
+  myproj:
+    regoin: us-east-2
+    TagKey: project
+    nothingLikeAFlag: 1
+`,
+			wantErr: []string{
+				`:4: unknown setting "regoin" (did you mean "region"?)`,
+				`:5: unknown setting "TagKey" (did you mean "tagKey"?)`,
+				`:6: unknown setting "nothingLikeAFlag"`,
+			},
+		},
+		{
+			name: "duplicate profiles",
+			file: `
+profiles:
+  myproj:
+    region: us-east-2
+  myproj:
+    region: us-west-2
+`,
+			wantErr: []string{`:5: profile "myproj" is defined twice`},
+		},
+		{
+			name: "duplicate settings",
+			file: `
+profiles:
+  myproj:
+    region: us-east-2
+    region: us-west-2
+`,
+			wantErr: []string{`:5: setting "region" is given twice`},
+		},
+		{
+			name: "invalid values",
+			file: `
+profiles:
+  myproj:
+    retryTimeout: soon
+    typeConcurrency: [AWS::EKS::Nodegroup]
+    exclude: [[AWS::EKS::Cluster]]
+    region:
+`,
+			command: commandScrub,
+			wantErr: []string{
+				`:4: setting "retryTimeout": invalid value "soon"`,
+				`:5: setting "typeConcurrency": invalid value "AWS::EKS::Nodegroup"`,
+				`:6: setting "exclude": line 6: expected a list of values`,
+				`:7: setting "region": missing value`,
+			},
+		},
+		{
+			name: "guardrail override",
+			file: `
+profiles:
+  myproj:
+    iUnderstandThisIsNotASandboxAccount: ["123456789012"]
+`,
+			wantErr: []string{`:4: setting "iUnderstandThisIsNotASandboxAccount" can only be given on the command line`},
+		},
+		{
+			name: "config file flags",
+			file: `
+profiles:
+  myproj:
+    config: other.yaml
+    profile: other
+`,
+			wantErr: []string{
+				`:4: setting "config" can only be given on the command line`,
+				`:5: setting "profile" can only be given on the command line`,
+			},
+		},
+		{
+			name:    "missing profile",
+			file:    "profiles:\n  other: {}\n",
+			wantErr: []string{`no profile "myproj" (profiles are: other)`},
+		},
+		{
+			name:    "JSON",
+			file:    `{"profiles": {"myproj": {"region": "us-east-2", "protectId": ["vpc-1", "vpc-2"]}}}`,
+			command: commandScrub,
+			check: func(t *testing.T, c *cfg) {
+				if c.region != "us-east-2" || !slices.Equal(c.protectIDs, []string{"vpc-1", "vpc-2"}) {
+					t.Errorf("got region %q and protectId %q", c.region, c.protectIDs)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "scrub.yaml")
+			err := os.WriteFile(file, []byte(tt.file), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := cfg{command: tt.command}
+			if c.command == "" {
+				c.command = commandPlan
+			}
+			fs := newFlagSet(&c)
+			err = fs.Parse(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = applyConfigFile(fs, file, "myproj")
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("expected an error containing %q, got:\n%s", want, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, &c)
+		})
+	}
+}
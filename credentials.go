@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// loadAWSConfig loads the AWS config for the credential flags: a shared
+// config profile, and a role to assume with the profile's credentials (or
+// with a web identity token).
+func loadAWSConfig(ctx context.Context, c *cfg) (aws.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if c.region != "" {
+		opts = append(opts, awsconfig.WithRegion(c.region))
+	}
+	if c.awsProfile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(c.awsProfile))
+	}
+	ac, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("loading aws config: %s", err)
+	}
+	// with -regions we use the region from the environment to look
+	// up accounts and regions, or else the first region listed. A
+	// default would pick the partition for us, and be wrong in
+	// GovCloud or China.
+	if ac.Region == "" {
+		if len(c.regions) == 0 || c.regions[0] == "all" {
+			return aws.Config{}, errors.New("no region to look up the enabled regions from: set AWS_REGION, or list the -regions instead of 'all'")
+		}
+		ac.Region = c.regions[0]
+	}
+
+	if c.roleARN == "" {
+		return ac, nil
+	}
+
+	stsClient := sts.NewFromConfig(ac)
+	if c.webIdentityTokenFile != "" {
+		ac.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(stsClient, c.roleARN, stscreds.IdentityTokenFile(c.webIdentityTokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = c.roleSessionName
+			o.Duration = c.roleDuration
+		}))
+		return ac, nil
+	}
+
+	ac.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, c.roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = c.roleSessionName
+		o.Duration = c.roleDuration
+		if c.externalID != "" {
+			o.ExternalID = &c.externalID
+		}
+		if c.mfaSerial != "" {
+			o.SerialNumber = &c.mfaSerial
+			o.TokenProvider = func() (string, error) {
+				return promptMFAToken(c.mfaSerial)
+			}
+		}
+	}))
+	return ac, nil
+}
+
+// promptMFAToken asks for an MFA code on stderr, so it doesn't end up in
+// the list of resources on stdout.
+func promptMFAToken(serial string) (string, error) {
+	fmt.Fprintf(os.Stderr, "MFA code for %s: ", serial)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading MFA code: %s", err)
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aslatter/aws-project-scrub/internal/filter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+)
+
+// guardrails decide which accounts we're willing to run against at all.
+type guardrails struct {
+	// account-ids we always refuse
+	deny []string
+
+	// the IAM account alias must match one of these, if any are given
+	allowAlias []*filter.Glob
+
+	// the account's tags must match this, if it's given
+	accountFilter *filter.Expr
+
+	// file the account tags came from, and the tags by account-id;
+	// without a file they're looked up in AWS Organizations
+	tagFile string
+	tags    map[string]map[string]string
+
+	// accounts to run against whatever the alias or tags say
+	override []string
+
+	// config for looking up account tags, with the credentials we
+	// started with (which may be in the organization's management
+	// account, where another account's tags can be read)
+	org aws.Config
+
+	// looks up the IAM alias of an account
+	alias func(ctx context.Context, ac aws.Config) (string, error)
+}
+
+func loadGuardrails(c *cfg) (*guardrails, error) {
+	g := guardrails{
+		deny:     c.denyAccounts,
+		override: c.overrideGuardrails,
+		alias:    accountAlias,
+	}
+	for _, id := range g.deny {
+		if !isAccountID(id) {
+			return nil, fmt.Errorf("flag -denyAccounts: invalid account-id %q", id)
+		}
+	}
+	for _, id := range g.override {
+		if !isAccountID(id) {
+			return nil, fmt.Errorf("flag -iUnderstandThisIsNotASandboxAccount: invalid account-id %q", id)
+		}
+	}
+
+	for _, p := range c.allowAlias {
+		g.allowAlias = append(g.allowAlias, filter.NewGlob(p))
+	}
+	if c.accountFilter != "" {
+		var err error
+		g.accountFilter, err = filter.Parse(c.accountFilter)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -accountFilter: %s", err)
+		}
+	}
+
+	if c.accountTags == "" {
+		return &g, nil
+	}
+	g.tagFile = c.accountTags
+	g.tags = map[string]map[string]string{}
+
+	f, err := os.Open(c.accountTags)
+	if err != nil {
+		return nil, fmt.Errorf("opening account tags file: %s", err)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	line := 0
+	for s.Scan() {
+		line++
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		account := fields[0]
+		if !isAccountID(account) {
+			return nil, fmt.Errorf("%s:%d: invalid account-id %q", c.accountTags, line, account)
+		}
+		if _, ok := g.tags[account]; ok {
+			return nil, fmt.Errorf("%s:%d: account %s is listed twice", c.accountTags, line, account)
+		}
+		tags := map[string]string{}
+		for _, kv := range fields[1:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", c.accountTags, line, kv)
+			}
+			tags[k] = v
+		}
+		g.tags[account] = tags
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("reading account tags file: %s", err)
+	}
+
+	return &g, nil
+}
+
+// check returns an error saying why we won't run against an account (with
+// a config for that account), if we won't. Refusals other than -denyAccounts
+// can be overridden.
+func (g *guardrails) check(ctx context.Context, ac aws.Config, account string) error {
+	if slices.Contains(g.deny, account) {
+		return fmt.Errorf("refusing to run against account %s: it is on the -denyAccounts list (this can't be overridden)", account)
+	}
+
+	reason, err := g.refusal(ctx, ac, account)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return nil
+	}
+	if slices.Contains(g.override, account) {
+		log.Printf("warning: running against account %s even though %s, because of -iUnderstandThisIsNotASandboxAccount", account, reason)
+		return nil
+	}
+	return fmt.Errorf("refusing to run against account %s: %s (to run anyway, pass -iUnderstandThisIsNotASandboxAccount %s)", account, reason, account)
+}
+
+// refusal returns why the alias or tags of an account don't pass, or "".
+func (g *guardrails) refusal(ctx context.Context, ac aws.Config, account string) (string, error) {
+	if len(g.allowAlias) > 0 {
+		alias, err := g.alias(ctx, ac)
+		if err != nil {
+			return "", err
+		}
+		if alias == "" {
+			return fmt.Sprintf("it has no IAM account alias, and -allowAlias requires one matching %s", g.aliasPatterns()), nil
+		}
+		if !slices.ContainsFunc(g.allowAlias, func(p *filter.Glob) bool { return p.Match(alias) }) {
+			return fmt.Sprintf("its IAM account alias %q doesn't match -allowAlias %s", alias, g.aliasPatterns()), nil
+		}
+	}
+
+	if g.accountFilter != nil {
+		tags, ok := g.tags[account]
+		if g.tagFile == "" {
+			var err error
+			tags, err = accountTags(ctx, g.org, account)
+			if err != nil {
+				return "", err
+			}
+		} else if !ok {
+			return fmt.Sprintf("it isn't listed in the -accountTags file %s, so it can't match -accountFilter %q", g.tagFile, g.accountFilter), nil
+		}
+		if !g.accountFilter.Match(tags) {
+			return fmt.Sprintf("its account tags %s don't match -accountFilter %q", formatTags(tags), g.accountFilter), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (g *guardrails) aliasPatterns() string {
+	var l []string
+	for _, p := range g.allowAlias {
+		l = append(l, fmt.Sprintf("%q", p))
+	}
+	return strings.Join(l, " or ")
+}
+
+// accountAlias returns the IAM alias of an account, or "" if it has none.
+func accountAlias(ctx context.Context, ac aws.Config) (string, error) {
+	out, err := iam.NewFromConfig(ac).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		return "", fmt.Errorf("looking up the account alias (for -allowAlias): %s", err)
+	}
+	if len(out.AccountAliases) == 0 {
+		return "", nil
+	}
+	return out.AccountAliases[0], nil
+}
+
+// accountTags returns the tags AWS Organizations has for an account.
+func accountTags(ctx context.Context, ac aws.Config, account string) (map[string]string, error) {
+	p := organizations.NewListTagsForResourcePaginator(organizations.NewFromConfig(ac), &organizations.ListTagsForResourceInput{
+		ResourceId: &account,
+	})
+	tags := map[string]string{}
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("looking up the tags of account %s (for -accountFilter; an -accountTags file can be used instead): %s", account, err)
+		}
+		for _, t := range page.Tags {
+			tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+		}
+	}
+	return tags, nil
+}
+
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	var l []string
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		l = append(l, k+"="+tags[k])
+	}
+	return strings.Join(l, ",")
+}
+
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	return !strings.ContainsFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestLoadGuardrails(t *testing.T) {
+	tests := []struct {
+		name string
+		c    cfg
+		// contents of the -accountTags file, if any
+		tags string
+		// error substring, if the settings should be refused
+		wantErr string
+		want    map[string]map[string]string
+	}{
+		{
+			name: "account tags",
+			tags: `
+# sandboxes
+111111111111 env=sandbox team=core
+222222222222   env=sandbox	owner=
+
+333333333333
+`,
+			want: map[string]map[string]string{
+				"111111111111": {"env": "sandbox", "team": "core"},
+				"222222222222": {"env": "sandbox", "owner": ""},
+				"333333333333": {},
+			},
+		},
+		{
+			name:    "invalid account-id",
+			tags:    "111111111111 env=sandbox\n11111111111 env=sandbox\n",
+			wantErr: `:2: invalid account-id "11111111111"`,
+		},
+		{
+			name:    "duplicate account",
+			tags:    "111111111111 env=sandbox\n111111111111 env=prod\n",
+			wantErr: ":2: account 111111111111 is listed twice",
+		},
+		{
+			name:    "not KEY=VALUE",
+			tags:    "111111111111 sandbox\n",
+			wantErr: `:1: expected KEY=VALUE, got "sandbox"`,
+		},
+		{
+			name:    "empty key",
+			tags:    "111111111111 =sandbox\n",
+			wantErr: `:1: expected KEY=VALUE, got "=sandbox"`,
+		},
+		{
+			name:    "invalid denied account",
+			c:       cfg{denyAccounts: []string{"prod"}},
+			wantErr: `flag -denyAccounts: invalid account-id "prod"`,
+		},
+		{
+			name:    "invalid override",
+			c:       cfg{overrideGuardrails: []string{"1234"}},
+			wantErr: `flag -iUnderstandThisIsNotASandboxAccount: invalid account-id "1234"`,
+		},
+		{
+			name:    "invalid filter",
+			c:       cfg{accountFilter: "env=sandbox AND"},
+			wantErr: "parsing -accountFilter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.c
+			if tt.tags != "" {
+				c.accountTags = filepath.Join(t.TempDir(), "accounts.txt")
+				err := os.WriteFile(c.accountTags, []byte(tt.tags), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			g, err := loadGuardrails(&c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.EqualFunc(g.tags, tt.want, maps.Equal) {
+				t.Errorf("got tags %v, want %v", g.tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardrailsCheck(t *testing.T) {
+	tagFile := filepath.Join(t.TempDir(), "accounts.txt")
+	err := os.WriteFile(tagFile, []byte("111111111111 env=sandbox\n222222222222 env=prod\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		c       cfg
+		account string
+		// the account's IAM alias
+		alias string
+		// error substring, if the account should be refused
+		wantErr string
+	}{
+		{
+			name:    "no guardrails",
+			account: "111111111111",
+		},
+		{
+			name:    "denied",
+			c:       cfg{denyAccounts: []string{"222222222222"}},
+			account: "222222222222",
+			wantErr: "refusing to run against account 222222222222: it is on the -denyAccounts list (this can't be overridden)",
+		},
+		{
+			name:    "denied, with an override",
+			c:       cfg{denyAccounts: []string{"222222222222"}, overrideGuardrails: []string{"222222222222"}},
+			account: "222222222222",
+			wantErr: "it is on the -denyAccounts list (this can't be overridden)",
+		},
+		{
+			name:    "another account denied",
+			c:       cfg{denyAccounts: []string{"222222222222"}},
+			account: "111111111111",
+		},
+		{
+			name:    "alias matches",
+			c:       cfg{allowAlias: []string{"acme-prod", "acme-sandbox-*"}},
+			account: "111111111111",
+			alias:   "acme-sandbox-7",
+		},
+		{
+			name:    "alias doesn't match",
+			c:       cfg{allowAlias: []string{"acme-sandbox-*", "acme-dev"}},
+			account: "111111111111",
+			alias:   "acme-prod",
+			wantErr: `its IAM account alias "acme-prod" doesn't match -allowAlias "acme-sandbox-*" or "acme-dev" (to run anyway, pass -iUnderstandThisIsNotASandboxAccount 111111111111)`,
+		},
+		{
+			name:    "alias is only a prefix",
+			c:       cfg{allowAlias: []string{"acme-sandbox"}},
+			account: "111111111111",
+			alias:   "acme-sandbox-7",
+			wantErr: `its IAM account alias "acme-sandbox-7" doesn't match -allowAlias "acme-sandbox"`,
+		},
+		{
+			name:    "no alias",
+			c:       cfg{allowAlias: []string{"acme-sandbox-*"}},
+			account: "111111111111",
+			wantErr: `it has no IAM account alias, and -allowAlias requires one matching "acme-sandbox-*"`,
+		},
+		{
+			name:    "tags match",
+			c:       cfg{accountFilter: "env=sandbox", accountTags: tagFile},
+			account: "111111111111",
+		},
+		{
+			name:    "tags don't match",
+			c:       cfg{accountFilter: "env=sandbox", accountTags: tagFile},
+			account: "222222222222",
+			wantErr: `its account tags env=prod don't match -accountFilter "env=sandbox"`,
+		},
+		{
+			name:    "not in the tags file",
+			c:       cfg{accountFilter: "env=sandbox", accountTags: tagFile},
+			account: "333333333333",
+			wantErr: "it isn't listed in the -accountTags file " + tagFile,
+		},
+		{
+			name:    "overridden",
+			c:       cfg{accountFilter: "env=sandbox", accountTags: tagFile, overrideGuardrails: []string{"222222222222"}},
+			account: "222222222222",
+		},
+		{
+			name:    "overridden alias",
+			c:       cfg{allowAlias: []string{"acme-sandbox-*"}, overrideGuardrails: []string{"111111111111"}},
+			account: "111111111111",
+			alias:   "acme-prod",
+		},
+		{
+			name:    "another account overridden",
+			c:       cfg{accountFilter: "env=sandbox", accountTags: tagFile, overrideGuardrails: []string{"111111111111"}},
+			account: "222222222222",
+			wantErr: "don't match -accountFilter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := loadGuardrails(&tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.alias = func(ctx context.Context, ac aws.Config) (string, error) {
+				return tt.alias, nil
+			}
+
+			err = g.check(context.Background(), aws.Config{}, tt.account)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Package filter implements boolean expressions over resource tags, such as:
+//
+//	project=foo AND env!=prod AND (owner=ci OR owner=sandbox)
+//
+// Supported terms are:
+//
+//	key            the tag is present
+//	key=value      the tag has the value
+//	key!=value     the tag does not have the value (or is missing)
+//	key=~regex     the tag value matches the regular expression
+//	key!~regex     the tag value doesn't match the regular expression (or is missing)
+//
+// Values containing '*' or '?' are glob patterns. Terms are combined with
+// AND, OR and NOT (or &&, || and !) and grouped with parentheses. Keys and
+// values containing spaces or special characters may be double-quoted.
+package filter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An Expr is a parsed filter expression.
+type Expr struct {
+	root node
+}
+
+type node interface {
+	match(tags map[string]string) bool
+	String() string
+}
+
+// Match reports whether a set of tags satisfies the expression.
+func (e *Expr) Match(tags map[string]string) bool {
+	return e.root.match(tags)
+}
+
+func (e *Expr) String() string {
+	return e.root.String()
+}
+
+// And combines expressions, all of which must match. Nil expressions
+// are ignored.
+func And(exprs ...*Expr) *Expr {
+	var result *Expr
+	for _, e := range exprs {
+		if e == nil {
+			continue
+		}
+		if result == nil {
+			result = e
+			continue
+		}
+		result = &Expr{root: &andNode{left: result.root, right: e.root}}
+	}
+	return result
+}
+
+// Equals returns an expression which matches resources with
+// the tag 'key' set to exactly 'value'.
+func Equals(key, value string) *Expr {
+	return &Expr{root: &equalsNode{key: key, value: value}}
+}
+
+// A Term is a condition every matching resource must satisfy, simple
+// enough to hand to AWS APIs which filter by tag.
+type Term struct {
+	Key string
+	// Value is the value the tag must have. If Exists is set, the tag
+	// only needs to be present.
+	Value  string
+	Exists bool
+	// Glob is set if Value is a glob pattern ('*' and '?' wildcards)
+	// rather than a literal.
+	Glob bool
+}
+
+// Match reports whether a set of tags satisfies the term.
+func (t Term) Match(tags map[string]string) bool {
+	return t.node().match(tags)
+}
+
+func (t Term) String() string {
+	return t.node().String()
+}
+
+func (t Term) node() node {
+	switch {
+	case t.Exists:
+		return &existsNode{key: t.Key}
+	case t.Glob:
+		return newGlobNode(t.Key, t.Value)
+	}
+	return &equalsNode{key: t.Key, value: t.Value}
+}
+
+// RequiredTerms returns the terms joined by AND at the top of the
+// expression. Every resource matching the expression matches all of these
+// terms, so they can be used to narrow a search before evaluating the full
+// expression.
+func (e *Expr) RequiredTerms() []Term {
+	if e == nil {
+		return nil
+	}
+	var result []Term
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *andNode:
+			walk(n.left)
+			walk(n.right)
+		case *existsNode:
+			result = append(result, Term{Key: n.key, Exists: true})
+		case *equalsNode:
+			result = append(result, Term{Key: n.key, Value: n.value})
+		case *globNode:
+			result = append(result, Term{Key: n.key, Value: n.glob.pattern, Glob: true})
+		}
+	}
+	walk(e.root)
+	return result
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) match(tags map[string]string) bool {
+	return n.left.match(tags) && n.right.match(tags)
+}
+
+func (n *andNode) String() string {
+	return "(" + n.left.String() + " AND " + n.right.String() + ")"
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) match(tags map[string]string) bool {
+	return n.left.match(tags) || n.right.match(tags)
+}
+
+func (n *orNode) String() string {
+	return "(" + n.left.String() + " OR " + n.right.String() + ")"
+}
+
+type notNode struct {
+	inner node
+}
+
+func (n *notNode) match(tags map[string]string) bool {
+	return !n.inner.match(tags)
+}
+
+func (n *notNode) String() string {
+	return "NOT " + n.inner.String()
+}
+
+type existsNode struct {
+	key string
+}
+
+func (n *existsNode) match(tags map[string]string) bool {
+	_, ok := tags[n.key]
+	return ok
+}
+
+func (n *existsNode) String() string {
+	return quote(n.key)
+}
+
+type equalsNode struct {
+	key   string
+	value string
+}
+
+func (n *equalsNode) match(tags map[string]string) bool {
+	v, ok := tags[n.key]
+	return ok && v == n.value
+}
+
+func (n *equalsNode) String() string {
+	// literal values containing wildcards must be quoted so they don't
+	// parse as globs
+	if strings.ContainsAny(n.value, "*?") {
+		return quote(n.key) + "=" + strconv.Quote(n.value)
+	}
+	return quote(n.key) + "=" + quote(n.value)
+}
+
+type globNode struct {
+	key  string
+	glob *Glob
+}
+
+func newGlobNode(key, pattern string) *globNode {
+	return &globNode{key: key, glob: NewGlob(pattern)}
+}
+
+func (n *globNode) match(tags map[string]string) bool {
+	v, ok := tags[n.key]
+	return ok && n.glob.Match(v)
+}
+
+func (n *globNode) String() string {
+	return quote(n.key) + "=" + quote(n.glob.pattern)
+}
+
+// A Glob is a pattern with '*' (any characters, including none) and '?'
+// (any single character) wildcards.
+type Glob struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewGlob compiles a glob pattern.
+func NewGlob(pattern string) *Glob {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return &Glob{
+		pattern: pattern,
+		re:      regexp.MustCompile(sb.String()),
+	}
+}
+
+// Match reports whether a string matches the pattern. A nil Glob
+// matches everything.
+func (g *Glob) Match(s string) bool {
+	return g == nil || g.re.MatchString(s)
+}
+
+// Prefix returns the literal part of the pattern before its first
+// wildcard. Every matching string starts with it.
+func (g *Glob) Prefix() string {
+	if g == nil {
+		return ""
+	}
+	prefix, _, _ := strings.Cut(g.pattern, "*")
+	prefix, _, _ = strings.Cut(prefix, "?")
+	return prefix
+}
+
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+type regexNode struct {
+	key string
+	re  *regexp.Regexp
+}
+
+func (n *regexNode) match(tags map[string]string) bool {
+	v, ok := tags[n.key]
+	return ok && n.re.MatchString(v)
+}
+
+func (n *regexNode) String() string {
+	return quote(n.key) + "=~" + quote(n.re.String())
+}
+
+// quote quotes a key or value if it wouldn't parse as a bare word.
+func quote(s string) string {
+	if s == "" || strings.ContainsFunc(s, isSpecial) || isKeyword(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,289 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+	tokenEquals
+	tokenNotEquals
+	tokenMatches
+	tokenNotMatches
+)
+
+type token struct {
+	kind tokenKind
+	// word text (unquoted)
+	text string
+	// quoted words are never globs or keywords
+	quoted bool
+	// offset into the input, for errors
+	pos int
+}
+
+func isSpecial(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`()!=~&|"`, r)
+}
+
+func isKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "AND", "OR", "NOT":
+		return true
+	}
+	return false
+}
+
+func tokenize(s string) ([]token, error) {
+	var result []token
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			result = append(result, token{kind: tokenLParen, pos: i})
+			i++
+		case r == ')':
+			result = append(result, token{kind: tokenRParen, pos: i})
+			i++
+		case strings.HasPrefix(s[i:], "&&"):
+			result = append(result, token{kind: tokenAnd, pos: i})
+			i += 2
+		case strings.HasPrefix(s[i:], "||"):
+			result = append(result, token{kind: tokenOr, pos: i})
+			i += 2
+		case strings.HasPrefix(s[i:], "!="):
+			result = append(result, token{kind: tokenNotEquals, pos: i})
+			i += 2
+		case strings.HasPrefix(s[i:], "!~"):
+			result = append(result, token{kind: tokenNotMatches, pos: i})
+			i += 2
+		case strings.HasPrefix(s[i:], "=~"):
+			result = append(result, token{kind: tokenMatches, pos: i})
+			i += 2
+		case r == '=':
+			result = append(result, token{kind: tokenEquals, pos: i})
+			i++
+		case r == '!':
+			result = append(result, token{kind: tokenNot, pos: i})
+			i++
+		case r == '"':
+			// find the closing quote, skipping escapes
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("at offset %d: unterminated quoted string", i)
+			}
+			text, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("at offset %d: invalid quoted string: %s", i, err)
+			}
+			result = append(result, token{kind: tokenWord, text: text, quoted: true, pos: i})
+			i = end + 1
+		case r == '&' || r == '|' || r == '~':
+			return nil, fmt.Errorf("at offset %d: unexpected %q", i, r)
+		default:
+			end := i
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if isSpecial(r) {
+					break
+				}
+				end += size
+			}
+			word := s[i:end]
+			t := token{kind: tokenWord, text: word, pos: i}
+			switch strings.ToUpper(word) {
+			case "AND":
+				t.kind = tokenAnd
+			case "OR":
+				t.kind = tokenOr
+			case "NOT":
+				t.kind = tokenNot
+			}
+			result = append(result, t)
+			i = end
+		}
+	}
+	result = append(result, token{kind: tokenEOF, pos: len(s)})
+	return result, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+// Parse parses a filter expression. NOT binds tighter than AND, which
+// binds tighter than OR.
+func Parse(s string) (*Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("at offset %d: unexpected %s", t.pos, describe(t))
+	}
+	return &Expr{root: n}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.take()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.take()
+	switch t.kind {
+	case tokenNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("at offset %d: expected ')', got %s", closing.pos, describe(closing))
+		}
+		return inner, nil
+	case tokenWord:
+		return p.parseTerm(t)
+	}
+	return nil, fmt.Errorf("at offset %d: expected a tag key, got %s", t.pos, describe(t))
+}
+
+func (p *parser) parseTerm(key token) (node, error) {
+	op := p.peek()
+	switch op.kind {
+	case tokenEquals, tokenNotEquals, tokenMatches, tokenNotMatches:
+		p.take()
+	default:
+		// bare key
+		return &existsNode{key: key.text}, nil
+	}
+
+	value := p.take()
+	if value.kind != tokenWord {
+		return nil, fmt.Errorf("at offset %d: expected a value for %q, got %s", value.pos, key.text, describe(value))
+	}
+
+	var n node
+	switch op.kind {
+	case tokenEquals, tokenNotEquals:
+		if !value.quoted && strings.ContainsAny(value.text, "*?") {
+			n = newGlobNode(key.text, value.text)
+		} else {
+			n = &equalsNode{key: key.text, value: value.text}
+		}
+	case tokenMatches, tokenNotMatches:
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("at offset %d: invalid regular expression for %q: %s", value.pos, key.text, err)
+		}
+		n = &regexNode{key: key.text, re: re}
+	}
+
+	if op.kind == tokenNotEquals || op.kind == tokenNotMatches {
+		n = &notNode{inner: n}
+	}
+	return n, nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenWord:
+		return strconv.Quote(t.text)
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenEquals:
+		return "'='"
+	case tokenNotEquals:
+		return "'!='"
+	case tokenMatches:
+		return "'=~'"
+	case tokenNotMatches:
+		return "'!~'"
+	}
+	return "?"
+}
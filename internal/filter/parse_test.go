@@ -0,0 +1,194 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		// canonical form
+		want string
+	}{
+		{`project=foo`, `project=foo`},
+		{`project`, `project`},
+		{`env!=prod`, `NOT env=prod`},
+		{`a=1 AND b=2 OR c=3`, `((a=1 AND b=2) OR c=3)`},
+		{`a=1 OR b=2 AND c=3`, `(a=1 OR (b=2 AND c=3))`},
+		{`NOT a=1 AND b=2`, `(NOT a=1 AND b=2)`},
+		{`NOT (a=1 AND b=2)`, `NOT (a=1 AND b=2)`},
+		{`a=1 && (b=2 || !c)`, `(a=1 AND (b=2 OR NOT c))`},
+		{`a=1 and b=2 or not c`, `((a=1 AND b=2) OR NOT c)`},
+		{`"my key"="a value"`, `"my key"="a value"`},
+		{`name="AND"`, `name="AND"`},
+		{`name="a*"`, `name="a*"`},
+		{`name="say \"hi\""`, `name="say \"hi\""`},
+		{`name=web-*`, `name=web-*`},
+		{`name=web-?`, `name=web-?`},
+		{`name=~^web-[0-9]+$`, `name=~^web-[0-9]+$`},
+		{`name!~"a b"`, `NOT name=~"a b"`},
+		{`name=~"a\\.(b|c)"`, `name=~"a\\.(b|c)"`},
+		{`name=~a\.b`, `name=~a\.b`},
+		{`owner=à`, `owner=à`},
+		{"owner=à AND café", `(owner=à AND café)`},
+		{"a=1\u00a0AND\u00a0b=2", `(a=1 AND b=2)`},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.expr, err)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+
+		// the string-form parses back to the same expression
+		again, err := Parse(e.String())
+		if err != nil {
+			t.Errorf("Parse(%q): %s", e.String(), err)
+			continue
+		}
+		if again.String() != e.String() {
+			t.Errorf("Parse(%q) = %s, not a round-trip", e.String(), again)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{``, "empty filter expression"},
+		{`a=`, `at offset 2: expected a value for "a", got end of expression`},
+		{`a=1 AND`, "at offset 7: expected a tag key, got end of expression"},
+		{`(a=1`, "at offset 4: expected ')', got end of expression"},
+		{`a=1)`, "at offset 3: unexpected ')'"},
+		{`a=1 b=2`, `at offset 4: unexpected "b"`},
+		{`a & b`, "at offset 2: unexpected '&'"},
+		{`a="b`, "at offset 2: unterminated quoted string"},
+		{`a=~"("`, `invalid regular expression for "a"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil {
+			t.Errorf("Parse(%q): expected an error", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): got error %q, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tags := map[string]string{
+		"project": "foo",
+		"env":     "dev",
+		"owner":   "à",
+		"name":    "web-12",
+		"my key":  "a value",
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`project=foo`, true},
+		{`project=bar`, false},
+		{`project`, true},
+		{`missing`, false},
+		{`env!=prod`, true},
+		{`missing!=x`, true},
+		{`owner=à`, true},
+		{`NOT owner=à`, false},
+		{`owner!=à`, false},
+		{`name=web-*`, true},
+		{`name="web-*"`, false},
+		{`name=web-?`, false},
+		{`name=web-??`, true},
+		{`name=~^web-[0-9]+$`, true},
+		{`name!~^web-`, false},
+		{`missing!~x`, true},
+		{`"my key"="a value"`, true},
+		{`project=bar OR env=dev AND name=web-*`, true},
+		{`(project=bar OR env=dev) AND name=db-*`, false},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.expr, err)
+			continue
+		}
+		if got := e.Match(tags); got != tt.want {
+			t.Errorf("Parse(%q).Match() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestRequiredTerms(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []Term
+	}{
+		{`project=foo`, []Term{{Key: "project", Value: "foo"}}},
+		{`project=foo AND owner AND name=web-*`, []Term{
+			{Key: "project", Value: "foo"},
+			{Key: "owner", Exists: true},
+			{Key: "name", Value: "web-*", Glob: true},
+		}},
+		{`project=foo AND (env=dev OR env=test)`, []Term{{Key: "project", Value: "foo"}}},
+		{`project=foo AND env!=prod AND name=~web`, []Term{{Key: "project", Value: "foo"}}},
+		{`project=foo OR owner=me`, nil},
+		{`NOT project=foo`, nil},
+		{`name="web-*"`, []Term{{Key: "name", Value: "web-*"}}},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.expr, err)
+			continue
+		}
+		if got := e.RequiredTerms(); !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(%q).RequiredTerms() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if terms := And(Equals("a", "1"), nil, Equals("b", "2")).RequiredTerms(); len(terms) != 2 {
+		t.Errorf("expected two terms from And, got %v", terms)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"myproj-*", "myproj-logs", true},
+		{"myproj-*", "myproj-", true},
+		{"myproj-*", "other", false},
+		{"*-sandbox", "acme-sandbox", true},
+		{"*-sandbox", "acme-sandbox-2", false},
+		{"a?c", "abc", true},
+		{"a?c", "àbc", false},
+		{"?bc", "àbc", true},
+		{"a.c", "abc", false},
+		{"/aws/*", "/aws/eks/x", true},
+	}
+	for _, tt := range tests {
+		if got := NewGlob(tt.pattern).Match(tt.s); got != tt.want {
+			t.Errorf("NewGlob(%q).Match(%q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+
+	if p := NewGlob("myproj-*-x?").Prefix(); p != "myproj-" {
+		t.Errorf("expected prefix myproj-, got %q", p)
+	}
+	var g *Glob
+	if !g.Match("anything") || g.Prefix() != "" {
+		t.Errorf("expected a nil glob to match everything")
+	}
+}
@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// cloudFormationTypes maps CloudFormation resource-types to our own, where
+// they differ. Our "AWS::IAM::Policy" is a managed policy, but the
+// CloudFormation type of that name is an inline policy (which goes away
+// with its role).
+var cloudFormationTypes = map[string]string{
+	"AWS::IAM::ManagedPolicy": ResourceTypeIAMPolicy,
+	"AWS::IAM::Policy":        "",
+}
+
+// FindStackResources returns the resources created by a CloudFormation
+// stack and its nested stacks, for the resource-types we have providers for.
+// It also returns descriptions of the stack's resources we can't handle.
+// The resources' tags are looked up, so protected ones are kept.
+func FindStackResources(ctx context.Context, s *Settings, providers []ResourceProvider, stack string) ([]Resource, []string, error) {
+	byType := map[string]ResourceProvider{}
+	for _, p := range providers {
+		byType[p.Type()] = p
+	}
+
+	c := cloudformation.NewFromConfig(s.AwsConfig)
+
+	var found []Resource
+	var skipped []string
+
+	stacks := []string{stack}
+	for len(stacks) > 0 {
+		stack := stacks[0]
+		stacks = stacks[1:]
+
+		// DescribeStackResources stops at 100 resources, so we
+		// page through ListStackResources instead.
+		p := cloudformation.NewListStackResourcesPaginator(c, &cloudformation.ListStackResourcesInput{
+			StackName: &stack,
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("listing resources of stack %s: %s", stack, err)
+			}
+			for _, sr := range page.StackResourceSummaries {
+				if sr.ResourceType == nil || sr.PhysicalResourceId == nil || *sr.PhysicalResourceId == "" {
+					// never created
+					continue
+				}
+				if sr.ResourceStatus == types.ResourceStatusDeleteComplete {
+					continue
+				}
+
+				typ := *sr.ResourceType
+				id := *sr.PhysicalResourceId
+				if typ == "AWS::CloudFormation::Stack" {
+					stacks = append(stacks, id)
+					continue
+				}
+				if ourType, ok := cloudFormationTypes[typ]; ok {
+					typ = ourType
+				}
+				provider, ok := byType[typ]
+				if !ok {
+					skipped = append(skipped, fmt.Sprintf("%s %s (in stack %s)", *sr.ResourceType, id, stack))
+					continue
+				}
+
+				r, err := LookupResource(ctx, s, provider, id)
+				if err != nil {
+					return nil, nil, fmt.Errorf("looking up %s %s: %s", typ, id, err)
+				}
+				found = append(found, r)
+			}
+		}
+	}
+
+	err := AddTags(ctx, s, providers, found)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return found, skipped, nil
+}
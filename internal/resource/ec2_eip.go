@@ -3,10 +3,10 @@ package resource
 import (
 	"context"
 	"fmt"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 type ec2EIP struct{}
@@ -27,6 +27,31 @@ func (e *ec2EIP) Dependencies() []string {
 	return []string{ResourceTypeEC2VPC}
 }
 
+// LookupResource implements HasResourceLookup. Addresses may be
+// identified by their allocation-ID or their public IP.
+func (e *ec2EIP) LookupResource(ctx context.Context, s *Settings, id string) (Resource, error) {
+	if strings.HasPrefix(id, "eipalloc-") {
+		return Resource{Type: e.Type(), ID: []string{id}}, nil
+	}
+
+	c := ec2.NewFromConfig(s.AwsConfig)
+	addresses, err := c.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		PublicIps: []string{id},
+	})
+	if err != nil {
+		return Resource{}, fmt.Errorf("describe addresses: %s", err)
+	}
+	if len(addresses.Addresses) != 1 || addresses.Addresses[0].AllocationId == nil {
+		return Resource{}, fmt.Errorf("no allocation-id found for address %s", id)
+	}
+
+	var r Resource
+	r.Type = e.Type()
+	r.ID = []string{*addresses.Addresses[0].AllocationId}
+	r.Tags = ec2Tags(addresses.Addresses[0].Tags)
+	return r, nil
+}
+
 // FindResources implements ResourceProvider.
 func (e *ec2EIP) FindResources(ctx context.Context, s *Settings) ([]Resource, error) {
 	var result []Resource
@@ -34,12 +59,7 @@ func (e *ec2EIP) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 	c := ec2.NewFromConfig(s.AwsConfig)
 
 	addresses, err := c.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:" + s.Filter.TagKey),
-				Values: []string{s.Filter.TagValue},
-			},
-		},
+		Filters: ec2TagFilters(s),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("describe addresses: %s", err)
@@ -63,6 +83,16 @@ func (e *ec2EIP) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 	return result, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *ec2EIP) TaggingAPIType() string {
+	return "ec2:elastic-ip"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *ec2EIP) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: e.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
 // Type implements ResourceProvider.
 func (e *ec2EIP) Type() string {
 	return ResourceTypeEC2EIP
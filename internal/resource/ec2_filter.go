@@ -0,0 +1,42 @@
+package resource
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ec2TagFilters translates the parts of the settings' filter which every
+// matching resource must satisfy into EC2 API filters. The full filter is
+// still evaluated against the returned resources.
+func ec2TagFilters(s *Settings) []types.Filter {
+	var result []types.Filter
+	for _, t := range s.Filter.RequiredTerms() {
+		if t.Exists {
+			result = append(result, types.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []string{t.Key},
+			})
+			continue
+		}
+		value := t.Value
+		if !t.Glob {
+			value = ec2EscapeFilterValue(value)
+		} else {
+			// the EC2 API treats '*' and '?' as wildcards, same as us,
+			// but backslashes are escapes.
+			value = strings.ReplaceAll(value, `\`, `\\`)
+		}
+		result = append(result, types.Filter{
+			Name:   aws.String("tag:" + t.Key),
+			Values: []string{value},
+		})
+	}
+	return result
+}
+
+// ec2EscapeFilterValue escapes wildcards in a literal filter value.
+func ec2EscapeFilterValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(v)
+}
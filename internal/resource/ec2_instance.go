@@ -24,7 +24,7 @@ func (e *ec2Instance) DeleteResource(ctx context.Context, s *Settings, r Resourc
 		InstanceIds: []string{r.ID[0]},
 	}, defaultDeleteWaitTime)
 	if err != nil {
-		return fmt.Errorf("waiting for instance termination: %s", err)
+		return fmt.Errorf("waiting for instance termination: %w", err)
 	}
 
 	return nil
@@ -4,9 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 type internetGateway struct{}
@@ -33,7 +32,7 @@ func (i *internetGateway) DeleteResource(ctx context.Context, s *Settings, r Res
 			VpcId:             gws.InternetGateways[0].Attachments[0].VpcId,
 		})
 		if err != nil {
-			return fmt.Errorf("detaching internet gateway: %s", err)
+			return fmt.Errorf("detaching internet gateway: %w", err)
 		}
 	}
 
@@ -49,12 +48,7 @@ func (i *internetGateway) FindResources(ctx context.Context, s *Settings) ([]Res
 
 	c := ec2.NewFromConfig(s.AwsConfig)
 	igp := ec2.NewDescribeInternetGatewaysPaginator(c, &ec2.DescribeInternetGatewaysInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:" + s.Filter.TagKey),
-				Values: []string{s.Filter.TagValue},
-			},
-		},
+		Filters: ec2TagFilters(s),
 	})
 
 	for igp.HasMorePages() {
@@ -91,6 +85,16 @@ func (i *internetGateway) Dependencies() []string {
 	}
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (i *internetGateway) TaggingAPIType() string {
+	return "ec2:internet-gateway"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (i *internetGateway) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: i.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
 // Type implements ResourceProvider.
 func (i *internetGateway) Type() string {
 	return ResourceTypeEC2InternetGateway
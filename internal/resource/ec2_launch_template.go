@@ -5,8 +5,8 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 type ec2LaunchTemplate struct{}
@@ -24,12 +24,7 @@ func (*ec2LaunchTemplate) FindResources(ctx context.Context, s *Settings) ([]Res
 	var result []Resource
 	c := ec2.NewFromConfig(s.AwsConfig)
 	p := ec2.NewDescribeLaunchTemplatesPaginator(c, &ec2.DescribeLaunchTemplatesInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:" + s.Filter.TagKey),
-				Values: []string{s.Filter.TagValue},
-			},
-		},
+		Filters: ec2TagFilters(s),
 	})
 	for p.HasMorePages() {
 		lts, err := p.NextPage(ctx)
@@ -41,6 +36,7 @@ func (*ec2LaunchTemplate) FindResources(ctx context.Context, s *Settings) ([]Res
 			r.Type = ResourceTypeEC2LaunchTemplate
 			r.ID = []string{*lt.LaunchTemplateId}
 			r.Tags = map[string]string{}
+			r.CreatedAt = aws.ToTime(lt.CreateTime)
 			result = append(result, r)
 
 			for _, t := range lt.Tags {
@@ -55,6 +51,16 @@ func (*ec2LaunchTemplate) FindResources(ctx context.Context, s *Settings) ([]Res
 	return result, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *ec2LaunchTemplate) TaggingAPIType() string {
+	return "ec2:launch-template"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *ec2LaunchTemplate) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: e.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
 // Type implements ResourceProvider.
 func (e *ec2LaunchTemplate) Type() string {
 	return ResourceTypeEC2LaunchTemplate
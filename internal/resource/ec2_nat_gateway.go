@@ -25,7 +25,7 @@ func (n *natGateway) DeleteResource(ctx context.Context, s *Settings, r Resource
 		NatGatewayIds: []string{r.ID[0]},
 	}, defaultDeleteWaitTime)
 	if err != nil {
-		return fmt.Errorf("waiting for deletion: %s", err)
+		return fmt.Errorf("waiting for deletion: %w", err)
 	}
 
 	return nil
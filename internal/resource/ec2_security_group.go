@@ -56,6 +56,7 @@ func (*securityGroup) DependentResources(ctx context.Context, s *Settings, r Res
 			ruleType,
 			*rule.SecurityGroupRuleId,
 		}
+		r.Tags = ec2Tags(rule.Tags)
 		results = append(results, r)
 	}
 
@@ -0,0 +1,15 @@
+package resource
+
+import "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+// ec2Tags converts EC2 tags to a map.
+func ec2Tags(ts []types.Tag) map[string]string {
+	result := map[string]string{}
+	for _, t := range ts {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		result[*t.Key] = *t.Value
+	}
+	return result
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 type ec2Volume struct{}
@@ -32,12 +32,7 @@ func (e *ec2Volume) FindResources(ctx context.Context, s *Settings) ([]Resource,
 	c := ec2.NewFromConfig(s.AwsConfig)
 
 	p := ec2.NewDescribeVolumesPaginator(c, &ec2.DescribeVolumesInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:" + s.Filter.TagKey),
-				Values: []string{s.Filter.TagValue},
-			},
-		},
+		Filters: ec2TagFilters(s),
 	})
 	for p.HasMorePages() {
 		vs, err := p.NextPage(ctx)
@@ -49,6 +44,7 @@ func (e *ec2Volume) FindResources(ctx context.Context, s *Settings) ([]Resource,
 			r.Type = e.Type()
 			r.ID = []string{*v.VolumeId}
 			r.Tags = map[string]string{}
+			r.CreatedAt = aws.ToTime(v.CreateTime)
 			result = append(result, r)
 
 			for _, t := range v.Tags {
@@ -63,6 +59,16 @@ func (e *ec2Volume) FindResources(ctx context.Context, s *Settings) ([]Resource,
 	return result, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *ec2Volume) TaggingAPIType() string {
+	return "ec2:volume"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *ec2Volume) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: e.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
 // Type implements ResourceProvider.
 func (e *ec2Volume) Type() string {
 	return ResourceTypeEC2Volume
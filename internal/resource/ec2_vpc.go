@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
@@ -55,6 +56,8 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 				var r Resource
 				r.Type = ResourceTypeEC2Instance
 				r.ID = []string{*i.InstanceId}
+				r.Tags = ec2Tags(i.Tags)
+				r.CreatedAt = aws.ToTime(i.LaunchTime)
 				results = append(results, r)
 			}
 		}
@@ -77,7 +80,9 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 		for _, ngw := range ngs.NatGateways {
 			var r Resource
 			r.ID = []string{*ngw.NatGatewayId}
+			r.Tags = ec2Tags(ngw.Tags)
 			r.Type = ResourceTypeEC2NATGateway
+			r.CreatedAt = aws.ToTime(ngw.CreateTime)
 			results = append(results, r)
 		}
 	}
@@ -103,6 +108,7 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2Subnet
 			r.ID = []string{*s.SubnetId}
+			r.Tags = ec2Tags(s.Tags)
 			results = append(results, r)
 		}
 	}
@@ -128,6 +134,7 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2SecurityGroup
 			r.ID = []string{*sg.GroupId}
+			r.Tags = ec2Tags(sg.Tags)
 			results = append(results, r)
 		}
 	}
@@ -154,6 +161,7 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2NetworkACL
 			r.ID = []string{*acl.NetworkAclId}
+			r.Tags = ec2Tags(acl.Tags)
 			results = append(results, r)
 		}
 	}
@@ -188,6 +196,7 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2RouteTable
 			r.ID = []string{*rt.RouteTableId}
+			r.Tags = ec2Tags(rt.Tags)
 			results = append(results, r)
 		}
 	}
@@ -210,6 +219,7 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2EgressOnlyInternetGateway
 			r.ID = []string{*eig.EgressOnlyInternetGatewayId}
+			r.Tags = ec2Tags(eig.Tags)
 			results = append(results, r)
 		}
 	}
@@ -232,11 +242,15 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.Type = ResourceTypeEC2VPCEndpoint
 			r.ID = []string{*ve.VpcEndpointId}
+			r.Tags = ec2Tags(ve.Tags)
+			r.CreatedAt = aws.ToTime(ve.CreationTimestamp)
 			results = append(results, r)
 		}
 	}
 
-	// load balancers (NLB or ALB - Classic LBs are a different API)
+	// load balancers (NLB or ALB - Classic LBs are a different API),
+	// and their target groups. Their tags are looked up separately.
+	var elbResults []Resource
 	elbClient := elb.NewFromConfig(s.AwsConfig)
 	lbp := elb.NewDescribeLoadBalancersPaginator(elbClient, &elb.DescribeLoadBalancersInput{})
 	for lbp.HasMorePages() {
@@ -251,11 +265,11 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.ID = []string{*lb.LoadBalancerArn}
 			r.Type = ResourceTypeLoadBalancer
-			results = append(results, r)
+			r.CreatedAt = aws.ToTime(lb.CreatedTime)
+			elbResults = append(elbResults, r)
 		}
 	}
 
-	// load balancer target groups
 	lbtp := elb.NewDescribeTargetGroupsPaginator(elbClient, &elb.DescribeTargetGroupsInput{})
 	for lbtp.HasMorePages() {
 		tgs, err := lbtp.NextPage(ctx)
@@ -269,9 +283,14 @@ func (e *ec2Vpc) DependentResources(ctx context.Context, s *Settings, r Resource
 			var r Resource
 			r.ID = []string{*tg.TargetGroupArn}
 			r.Type = ResourceTypeLoadBalancerTargetGroup
-			results = append(results, r)
+			elbResults = append(elbResults, r)
 		}
 	}
+	err := addELBTags(ctx, elbClient, elbResults)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, elbResults...)
 
 	return results, nil
 }
@@ -295,12 +314,7 @@ func (e *ec2Vpc) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 	c := ec2.NewFromConfig(s.AwsConfig)
 
 	p := ec2.NewDescribeVpcsPaginator(c, &ec2.DescribeVpcsInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:" + s.Filter.TagKey),
-				Values: []string{s.Filter.TagValue},
-			},
-		},
+		Filters: ec2TagFilters(s),
 	})
 	for p.HasMorePages() {
 		vpcs, err := p.NextPage(ctx)
@@ -327,6 +341,16 @@ func (e *ec2Vpc) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 	return results, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *ec2Vpc) TaggingAPIType() string {
+	return "ec2:vpc"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *ec2Vpc) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: e.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
 // Type implements ResourceProvider.
 func (e *ec2Vpc) Type() string {
 	return ResourceTypeEC2VPC
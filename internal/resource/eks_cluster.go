@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 )
 
@@ -83,7 +86,7 @@ func (e *eksCluster) DeleteResource(ctx context.Context, s *Settings, r Resource
 		Name: &r.ID[0],
 	}, 3*defaultDeleteWaitTime)
 	if err != nil {
-		return fmt.Errorf("waiting for deletion: %s", err)
+		return fmt.Errorf("waiting for deletion: %w", err)
 	}
 
 	return nil
@@ -102,30 +105,47 @@ func (e *eksCluster) FindResources(ctx context.Context, s *Settings) ([]Resource
 		}
 
 		for _, k := range result.Clusters {
+			if !s.NamePattern.Match(k) {
+				continue
+			}
+
+			// describing the cluster gets us tags and the
+			// creation-time in one go.
+			d, err := c.DescribeCluster(ctx, &eks.DescribeClusterInput{
+				Name: &k,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("describing EKS cluster %q: %s", k, err)
+			}
+
 			var r Resource
 			r.Type = e.Type()
 			r.ID = []string{k}
 			r.Tags = map[string]string{}
+			maps.Copy(r.Tags, d.Cluster.Tags)
+			r.CreatedAt = aws.ToTime(d.Cluster.CreatedAt)
 			results = append(results, r)
-
-			// we need an ARN to look up the tags :-(
-			arn := fmt.Sprintf("arn:%s:eks:%s:%s:cluster/%s",
-				s.Partition, s.Region, s.Account, k,
-			)
-
-			ts, err := c.ListTagsForResource(ctx, &eks.ListTagsForResourceInput{
-				ResourceArn: &arn,
-			})
-			if err != nil {
-				return nil, fmt.Errorf("listing tags for EKS cluster %q: %s", k, err)
-			}
-			maps.Copy(r.Tags, ts.Tags)
 		}
 	}
 
 	return results, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *eksCluster) TaggingAPIType() string {
+	return "eks:cluster"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *eksCluster) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	return Resource{Type: e.Type(), ID: []string{arnResourceID(a)}}, true
+}
+
+// ResourceName implements HasResourceNames.
+func (e *eksCluster) ResourceName(r Resource) string {
+	return r.ID[0]
+}
+
 // Type implements ResourceProvider.
 func (e *eksCluster) Type() string {
 	return ResourceTypeEKSCluster
@@ -136,3 +156,26 @@ func init() {
 		return &eksCluster{}
 	})
 }
+
+// eksClusterChildID splits the identifier of a resource belonging to an
+// EKS cluster (a node group, for example) into the cluster name and the
+// resource's own name. Identifiers may be "cluster/name", "cluster|name"
+// or an ARN.
+func eksClusterChildID(id string) ([]string, error) {
+	if a, err := arn.Parse(id); err == nil {
+		// such as "nodegroup/cluster/name/uuid"
+		parts := strings.Split(a.Resource, "/")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("unexpected ARN %q", id)
+		}
+		return parts[1:3], nil
+	}
+
+	parts := strings.FieldsFunc(id, func(r rune) bool {
+		return r == '/' || r == '|'
+	})
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected id %q", id)
+	}
+	return parts, nil
+}
@@ -3,6 +3,7 @@ package resource
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/eks"
@@ -25,7 +26,7 @@ func (e *eksFargateProfile) DeleteResource(ctx context.Context, s *Settings, r R
 		FargateProfileName: &profile,
 	})
 	if err != nil {
-		return fmt.Errorf("deleting fargate profile %q: %s", profile, err)
+		return fmt.Errorf("deleting fargate profile %q: %w", profile, err)
 	}
 
 	w := eks.NewFargateProfileDeletedWaiter(c)
@@ -34,12 +35,35 @@ func (e *eksFargateProfile) DeleteResource(ctx context.Context, s *Settings, r R
 		FargateProfileName: &profile,
 	}, defaultDeleteWaitTime)
 	if err != nil {
-		return fmt.Errorf("waiting for deletion: %s", err)
+		return fmt.Errorf("waiting for deletion: %w", err)
 	}
 
 	return nil
 }
 
+// LookupResource implements HasResourceLookup.
+func (e *eksFargateProfile) LookupResource(ctx context.Context, s *Settings, id string) (Resource, error) {
+	ids, err := eksClusterChildID(id)
+	if err != nil {
+		return Resource{}, err
+	}
+	return Resource{Type: e.Type(), ID: ids}, nil
+}
+
+// ResourceTags implements HasResourceTags.
+func (e *eksFargateProfile) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	out, err := eks.NewFromConfig(s.AwsConfig).DescribeFargateProfile(ctx, &eks.DescribeFargateProfileInput{
+		ClusterName:        &r.ID[0],
+		FargateProfileName: &r.ID[1],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing fargate profile: %s", err)
+	}
+	tags := map[string]string{}
+	maps.Copy(tags, out.FargateProfile.Tags)
+	return tags, nil
+}
+
 // Type implements ResourceProvider.
 func (e *eksFargateProfile) Type() string {
 	return ResourceTypeEKSFargateProfile
@@ -3,6 +3,7 @@ package resource
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strings"
 	"time"
 
@@ -35,12 +36,35 @@ func (e *eksNodegroup) DeleteResource(ctx context.Context, s *Settings, r Resour
 	}, 15*time.Minute)
 
 	if err != nil {
-		return fmt.Errorf("waiting for deletion: %s", err)
+		return fmt.Errorf("waiting for deletion: %w", err)
 	}
 
 	return nil
 }
 
+// LookupResource implements HasResourceLookup.
+func (e *eksNodegroup) LookupResource(ctx context.Context, s *Settings, id string) (Resource, error) {
+	ids, err := eksClusterChildID(id)
+	if err != nil {
+		return Resource{}, err
+	}
+	return Resource{Type: e.Type(), ID: ids}, nil
+}
+
+// ResourceTags implements HasResourceTags.
+func (e *eksNodegroup) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	out, err := eks.NewFromConfig(s.AwsConfig).DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   &r.ID[0],
+		NodegroupName: &r.ID[1],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing nodegroup: %s", err)
+	}
+	tags := map[string]string{}
+	maps.Copy(tags, out.Nodegroup.Tags)
+	return tags, nil
+}
+
 // Type implements ResourceProvider.
 func (e *eksNodegroup) Type() string {
 	return ResourceTypeEKSNodegroup
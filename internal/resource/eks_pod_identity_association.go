@@ -2,6 +2,8 @@ package resource
 
 import (
 	"context"
+	"fmt"
+	"maps"
 
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 )
@@ -18,6 +20,29 @@ func (e *eksPodIdentityAssoc) DeleteResource(ctx context.Context, s *Settings, r
 	return err
 }
 
+// LookupResource implements HasResourceLookup.
+func (e *eksPodIdentityAssoc) LookupResource(ctx context.Context, s *Settings, id string) (Resource, error) {
+	ids, err := eksClusterChildID(id)
+	if err != nil {
+		return Resource{}, err
+	}
+	return Resource{Type: e.Type(), ID: ids}, nil
+}
+
+// ResourceTags implements HasResourceTags.
+func (e *eksPodIdentityAssoc) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	out, err := eks.NewFromConfig(s.AwsConfig).DescribePodIdentityAssociation(ctx, &eks.DescribePodIdentityAssociationInput{
+		ClusterName:   &r.ID[0],
+		AssociationId: &r.ID[1],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing pod identity association: %s", err)
+	}
+	tags := map[string]string{}
+	maps.Copy(tags, out.Association.Tags)
+	return tags, nil
+}
+
 // Type implements ResourceProvider.
 func (e *eksPodIdentityAssoc) Type() string {
 	return ResourceTypeEKSPodIdentityAssociation
@@ -24,12 +24,19 @@ func (e *elbLoadBalancer) DeleteResource(ctx context.Context, s *Settings, r Res
 		LoadBalancerArns: []string{r.ID[0]},
 	}, defaultDeleteWaitTime)
 	if err != nil {
-		return fmt.Errorf("waiting for load-balancer deletion: %s", err)
+		return fmt.Errorf("waiting for load-balancer deletion: %w", err)
 	}
 
 	return nil
 }
 
+// ResourceTags implements HasResourceTags.
+func (e *elbLoadBalancer) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	rs := []Resource{r}
+	err := addELBTags(ctx, elb.NewFromConfig(s.AwsConfig), rs)
+	return rs[0].Tags, err
+}
+
 // Type implements ResourceProvider.
 func (e *elbLoadBalancer) Type() string {
 	return ResourceTypeLoadBalancer
@@ -0,0 +1,52 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+)
+
+// elbTagBatch is the most ARNs DescribeTags takes at once.
+const elbTagBatch = 20
+
+// addELBTags looks up the tags of load balancers and target groups (by
+// the ARNs in their IDs) and fills them in.
+func addELBTags(ctx context.Context, c *elb.Client, rs []Resource) error {
+	byARN := map[string]*Resource{}
+	var arns []string
+	for i := range rs {
+		rs[i].Tags = map[string]string{}
+		byARN[rs[i].ID[0]] = &rs[i]
+		arns = append(arns, rs[i].ID[0])
+	}
+
+	for len(arns) > 0 {
+		batch := arns[:min(len(arns), elbTagBatch)]
+		arns = arns[len(batch):]
+
+		out, err := c.DescribeTags(ctx, &elb.DescribeTagsInput{
+			ResourceArns: batch,
+		})
+		if err != nil {
+			return fmt.Errorf("describing load balancer tags: %s", err)
+		}
+		for _, td := range out.TagDescriptions {
+			if td.ResourceArn == nil {
+				continue
+			}
+			r, ok := byARN[*td.ResourceArn]
+			if !ok {
+				continue
+			}
+			for _, t := range td.Tags {
+				if t.Key == nil || t.Value == nil {
+					continue
+				}
+				r.Tags[*t.Key] = *t.Value
+			}
+		}
+	}
+
+	return nil
+}
@@ -21,6 +21,13 @@ func (e *elbTargetGroup) DeleteResource(ctx context.Context, s *Settings, r Reso
 	return err
 }
 
+// ResourceTags implements HasResourceTags.
+func (e *elbTargetGroup) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	rs := []Resource{r}
+	err := addELBTags(ctx, elb.NewFromConfig(s.AwsConfig), rs)
+	return rs[0].Tags, err
+}
+
 // Type implements ResourceProvider.
 func (e *elbTargetGroup) Type() string {
 	return ResourceTypeLoadBalancerTargetGroup
@@ -2,6 +2,7 @@ package resource
 
 import (
 	"errors"
+	"strings"
 )
 
 func IsErrNotFound(err error) bool {
@@ -29,3 +30,30 @@ func IsErrNotFound(err error) bool {
 
 	return false
 }
+
+// IsErrDependency reports whether an error looks like a deletion failed because
+// something else is still using the resource. These are usually eventual-consistency
+// races (an ENI which outlives its instance, for example) and clear up on their
+// own, so it is worth trying again.
+func IsErrDependency(err error) bool {
+	var apiError interface {
+		ErrorCode() string
+		ErrorMessage() string
+	}
+	if !errors.As(err, &apiError) {
+		return false
+	}
+
+	code := apiError.ErrorCode()
+	switch code {
+	case "DependencyViolation", "ResourceInUse", "ResourceInUseException", "DeleteConflict":
+		return true
+	case "InvalidParameterValue":
+		// EC2 uses a generic code for some in-use errors, so we
+		// need to look at the message.
+		return strings.Contains(strings.ToLower(apiError.ErrorMessage()), "in use")
+	}
+
+	// for example "InvalidIPAddress.InUse"
+	return strings.HasSuffix(code, ".InUse")
+}
@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestIsErrDependency(t *testing.T) {
+	tests := []struct {
+		code    string
+		message string
+		want    bool
+	}{
+		{"DependencyViolation", "The vpc 'vpc-1' has dependencies and cannot be deleted.", true},
+		{"DeleteConflict", "Cannot delete entity, must detach all policies first.", true},
+		{"ResourceInUse", "", true},
+		{"ResourceInUseException", "", true},
+		{"InvalidIPAddress.InUse", "", true},
+		{"InvalidNetworkInterface.InUse", "", true},
+		{"InvalidParameterValue", "Network interface 'eni-1' is currently in use.", true},
+		{"InvalidParameterValue", "Network interface 'eni-1' is currently IN USE.", true},
+		{"InvalidParameterValue", "Invalid value 'x' for groupId.", false},
+		{"InvalidGroup.NotFound", "", false},
+		{"AccessDenied", "", false},
+		{"InUseSomewhere", "", false},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("deleting: %w", &smithy.GenericAPIError{Code: tt.code, Message: tt.message})
+		if got := IsErrDependency(err); got != tt.want {
+			t.Errorf("IsErrDependency(%s %q) = %v, want %v", tt.code, tt.message, got, tt.want)
+		}
+	}
+
+	// only API errors are dependency errors, and formatting with %s
+	// loses them
+	if IsErrDependency(errors.New("DependencyViolation")) {
+		t.Error("expected a plain error not to be a dependency error")
+	}
+	if IsErrDependency(fmt.Errorf("deleting: %s", &smithy.GenericAPIError{Code: "DependencyViolation"})) {
+		t.Errorf("expected an error formatted with %%s not to be a dependency error")
+	}
+}
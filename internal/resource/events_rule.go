@@ -3,7 +3,9 @@ package resource
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 )
 
@@ -26,7 +28,7 @@ func (e *eventsRule) DeleteResource(ctx context.Context, s *Settings, r Resource
 			Ids:  []string{*target.Id},
 		})
 		if err != nil {
-			return fmt.Errorf("removing rule target: %s", err)
+			return fmt.Errorf("removing rule target: %w", err)
 		}
 	}
 
@@ -46,14 +48,22 @@ func (*eventsRule) FindResources(ctx context.Context, s *Settings) ([]Resource,
 
 	var nextToken *string
 	for {
-		rules, err := c.ListRules(ctx, &eventbridge.ListRulesInput{
+		input := &eventbridge.ListRulesInput{
 			NextToken: nextToken,
-		})
+		}
+		if prefix := s.NamePattern.Prefix(); prefix != "" {
+			input.NamePrefix = &prefix
+		}
+		rules, err := c.ListRules(ctx, input)
 		if err != nil {
 			return nil, fmt.Errorf("listing rules: %s", err)
 		}
 
 		for _, rule := range rules.Rules {
+			if !s.NamePattern.Match(*rule.Name) {
+				continue
+			}
+
 			var r Resource
 			r.Type = ResourceTypeEventsRule
 			r.ID = []string{*rule.Name}
@@ -83,6 +93,27 @@ func (*eventsRule) FindResources(ctx context.Context, s *Settings) ([]Resource,
 	return result, nil
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (e *eventsRule) TaggingAPIType() string {
+	return "events:rule"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (e *eventsRule) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	// rules on other event-buses are "rule/bus-name/rule-name", and
+	// we only handle the default bus.
+	name := arnResourceID(a)
+	if strings.Contains(name, "/") {
+		return Resource{}, false
+	}
+	return Resource{Type: e.Type(), ID: []string{name}}, true
+}
+
+// ResourceName implements HasResourceNames.
+func (e *eventsRule) ResourceName(r Resource) string {
+	return r.ID[0]
+}
+
 // Type implements ResourceProvider.
 func (e *eventsRule) Type() string {
 	return ResourceTypeEventsRule
@@ -0,0 +1,67 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	smithyauth "github.com/aws/smithy-go/auth"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// regionPattern matches region names, such as us-east-1 and
+// us-gov-west-1. The SDK's endpoint rules put names they don't recognize
+// in the aws partition rather than rejecting them.
+var regionPattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)+-[0-9]+$`)
+
+// IsRegionName reports whether a string is shaped like a region name.
+func IsRegionName(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// GlobalRegion returns the region global resources (those of providers
+// implementing IsGlobal) are managed from, for a partition. The region is
+// one of the partition's, and is how the SDK's endpoint rules find the
+// global region: the one IAM requests are signed for. New partitions are
+// picked up with SDK updates.
+func GlobalRegion(ctx context.Context, partition, region string) (string, error) {
+	if !IsRegionName(region) {
+		return "", fmt.Errorf("unknown region %q", region)
+	}
+	if p, err := regionPartition(region); err != nil {
+		return "", err
+	} else if p != partition {
+		return "", fmt.Errorf("region %s is in partition %s, not %s", region, p, partition)
+	}
+
+	e, err := iam.NewDefaultEndpointResolverV2().ResolveEndpoint(ctx, iam.EndpointParameters{
+		Region: &region,
+	})
+	if err != nil {
+		return "", fmt.Errorf("resolving IAM endpoint for %s: %s", region, err)
+	}
+
+	opts, _ := smithyauth.GetAuthOptions(&e.Properties)
+	for _, o := range opts {
+		if r, ok := smithyhttp.GetSigV4SigningRegion(&o.SignerProperties); ok && r != "" {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("no signing region for the IAM endpoint %s", e.URI.String())
+}
+
+// regionPartition returns the partition the SDK's endpoint metadata puts
+// a region in. Names it doesn't recognize are put in the aws partition.
+func regionPartition(region string) (string, error) {
+	// the endpoint rules don't report the partition, but the older
+	// endpoint metadata does
+	e, err := iam.NewDefaultEndpointResolver().ResolveEndpoint(region, iam.EndpointResolverOptions{})
+	if err != nil {
+		return "", fmt.Errorf("resolving IAM endpoint for %s: %s", region, err)
+	}
+	if e.PartitionID == "" {
+		return "", fmt.Errorf("no partition for region %s", region)
+	}
+	return e.PartitionID, nil
+}
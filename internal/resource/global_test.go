@@ -0,0 +1,57 @@
+package resource
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGlobalRegion(t *testing.T) {
+	tests := []struct {
+		partition string
+		region    string
+		want      string
+		// error substring, if the region should be refused
+		wantErr string
+	}{
+		{"aws", "us-east-1", "us-east-1", ""},
+		{"aws", "eu-west-1", "us-east-1", ""},
+		{"aws", "ap-southeast-2", "us-east-1", ""},
+		{"aws-cn", "cn-north-1", "cn-north-1", ""},
+		{"aws-cn", "cn-northwest-1", "cn-north-1", ""},
+		{"aws-us-gov", "us-gov-west-1", "us-gov-west-1", ""},
+		{"aws-us-gov", "us-gov-east-1", "us-gov-west-1", ""},
+
+		// another partition's region
+		{"aws", "cn-north-1", "", "region cn-north-1 is in partition aws-cn, not aws"},
+		{"aws", "us-gov-west-1", "", "region us-gov-west-1 is in partition aws-us-gov, not aws"},
+		{"aws-cn", "us-east-1", "", "region us-east-1 is in partition aws, not aws-cn"},
+		{"aws-us-gov", "us-east-1", "", "region us-east-1 is in partition aws, not aws-us-gov"},
+
+		// names the SDK puts in the aws partition
+		{"aws-cn", "xx-fake-1", "", "region xx-fake-1 is in partition aws, not aws-cn"},
+		{"aws-us-gov", "us-east-99", "", "region us-east-99 is in partition aws, not aws-us-gov"},
+
+		// not region names
+		{"aws", "", "", `unknown region ""`},
+		{"aws", "us-east", "", `unknown region "us-east"`},
+		{"aws", "US-EAST-1", "", `unknown region "US-EAST-1"`},
+		{"aws", "us-east-1.amazonaws.com", "", `unknown region "us-east-1.amazonaws.com"`},
+	}
+	for _, tt := range tests {
+		got, err := GlobalRegion(context.Background(), tt.partition, tt.region)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s in %s: expected an error containing %q, got %q, %v", tt.region, tt.partition, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s in %s: %s", tt.region, tt.partition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s in %s: got %s, want %s", tt.region, tt.partition, got, tt.want)
+		}
+	}
+}
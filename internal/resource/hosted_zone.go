@@ -61,7 +61,7 @@ func (h *hostedZone) DeleteResource(ctx context.Context, s *Settings, r Resource
 					},
 				})
 				if err != nil {
-					return fmt.Errorf("updating record-sets: %s", err)
+					return fmt.Errorf("updating record-sets: %w", err)
 				}
 				changes = changes[:0]
 
@@ -69,7 +69,7 @@ func (h *hostedZone) DeleteResource(ctx context.Context, s *Settings, r Resource
 					Id: changeResult.ChangeInfo.Id,
 				}, defaultDeleteWaitTime)
 				if err != nil {
-					return fmt.Errorf("waiting for changeset: %s", err)
+					return fmt.Errorf("waiting for changeset: %w", err)
 				}
 			}
 		}
@@ -82,14 +82,14 @@ func (h *hostedZone) DeleteResource(ctx context.Context, s *Settings, r Resource
 			},
 		})
 		if err != nil {
-			return fmt.Errorf("updating record-sets: %s", err)
+			return fmt.Errorf("updating record-sets: %w", err)
 		}
 
 		err = w.Wait(ctx, &route53.GetChangeInput{
 			Id: changeResult.ChangeInfo.Id,
 		}, defaultDeleteWaitTime)
 		if err != nil {
-			return fmt.Errorf("waiting for changeset: %s", err)
+			return fmt.Errorf("waiting for changeset: %w", err)
 		}
 	}
 
@@ -99,6 +99,43 @@ func (h *hostedZone) DeleteResource(ctx context.Context, s *Settings, r Resource
 	return err
 }
 
+// LookupResource implements HasResourceLookup.
+func (h *hostedZone) LookupResource(ctx context.Context, s *Settings, id string) (Resource, error) {
+	id = strings.TrimPrefix(id, "/hostedzone/")
+
+	c := route53.NewFromConfig(s.AwsConfig)
+	z, err := c.GetHostedZone(ctx, &route53.GetHostedZoneInput{
+		Id: &id,
+	})
+	if err != nil {
+		return Resource{}, fmt.Errorf("getting hosted zone %s: %s", id, err)
+	}
+
+	var r Resource
+	r.Type = h.Type()
+	r.ID = []string{id, *z.HostedZone.Name}
+	return r, nil
+}
+
+// ResourceTags implements HasResourceTags.
+func (h *hostedZone) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	ts, err := route53.NewFromConfig(s.AwsConfig).ListTagsForResource(ctx, &route53.ListTagsForResourceInput{
+		ResourceId:   &r.ID[0],
+		ResourceType: types.TagResourceTypeHostedzone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags for zone %s: %s", r.ID[0], err)
+	}
+	tags := map[string]string{}
+	for _, t := range ts.ResourceTagSet.Tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		tags[*t.Key] = *t.Value
+	}
+	return tags, nil
+}
+
 // FindResources implements ResourceProvider.
 func (h *hostedZone) FindResources(ctx context.Context, s *Settings) ([]Resource, error) {
 	c := route53.NewFromConfig(s.AwsConfig)
@@ -115,6 +152,9 @@ func (h *hostedZone) FindResources(ctx context.Context, s *Settings) ([]Resource
 			if z.Id == nil || z.Name == nil {
 				continue
 			}
+			if !s.NamePattern.Match(strings.TrimSuffix(*z.Name, ".")) {
+				continue
+			}
 
 			var r Resource
 			r.Type = h.Type()
@@ -145,11 +185,24 @@ func (h *hostedZone) FindResources(ctx context.Context, s *Settings) ([]Resource
 	return foundZones, nil
 }
 
+// MaxConcurrency implements HasMaxConcurrency.
+func (*hostedZone) MaxConcurrency() int {
+	// Route53 allows five requests per second for the whole account, and
+	// deleting a zone means deleting all of its records first.
+	return 2
+}
+
 // IsGlobal implements ResourceProvider.
 func (h *hostedZone) IsGlobal() bool {
 	return true
 }
 
+// ResourceName implements HasResourceNames. Names are matched without
+// the trailing dot.
+func (h *hostedZone) ResourceName(r Resource) string {
+	return strings.TrimSuffix(r.ID[1], ".")
+}
+
 // Type implements ResourceProvider.
 func (h *hostedZone) Type() string {
 	return "AWS::Route53::HostedZone"
@@ -3,6 +3,7 @@ package resource
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -16,6 +17,16 @@ func (i *iamInstanceProfile) IsGlobal() bool {
 	return true
 }
 
+// MaxConcurrency implements HasMaxConcurrency.
+func (*iamInstanceProfile) MaxConcurrency() int {
+	return iamMaxConcurrency
+}
+
+// ConcurrencyGroup implements HasConcurrencyGroup.
+func (*iamInstanceProfile) ConcurrencyGroup() string {
+	return "IAM"
+}
+
 // DeleteResource implements ResourceProvider.
 func (i *iamInstanceProfile) DeleteResource(ctx context.Context, s *Settings, r Resource) error {
 	c := iam.NewFromConfig(s.AwsConfig)
@@ -47,7 +58,7 @@ func (i *iamInstanceProfile) DeleteResource(ctx context.Context, s *Settings, r
 			RoleName:            &roleName,
 		})
 		if err != nil {
-			return fmt.Errorf("removing role from instance profile: %s", err)
+			return fmt.Errorf("removing role from instance profile: %w", err)
 		}
 	}
 
@@ -58,6 +69,22 @@ func (i *iamInstanceProfile) DeleteResource(ctx context.Context, s *Settings, r
 	return err
 }
 
+// ResourceTags implements HasResourceTags.
+func (i *iamInstanceProfile) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	p := iam.NewListInstanceProfileTagsPaginator(iam.NewFromConfig(s.AwsConfig), &iam.ListInstanceProfileTagsInput{
+		InstanceProfileName: &r.ID[0],
+	})
+	tags := map[string]string{}
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing instance profile tags: %s", err)
+		}
+		maps.Copy(tags, iamTags(page.Tags))
+	}
+	return tags, nil
+}
+
 // Type implements Resource.
 func (i *iamInstanceProfile) Type() string {
 	return ResourceTypeIAMInstanceProfile
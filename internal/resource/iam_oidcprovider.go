@@ -3,6 +3,7 @@ package resource
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 
 	"context"
@@ -15,6 +16,16 @@ func (i *iamOIDCProvider) IsGlobal() bool {
 	return true
 }
 
+// MaxConcurrency implements HasMaxConcurrency.
+func (*iamOIDCProvider) MaxConcurrency() int {
+	return iamMaxConcurrency
+}
+
+// ConcurrencyGroup implements HasConcurrencyGroup.
+func (*iamOIDCProvider) ConcurrencyGroup() string {
+	return "IAM"
+}
+
 // DeleteResource implements ResourceProvider.
 func (i *iamOIDCProvider) DeleteResource(ctx context.Context, s *Settings, r Resource) error {
 	c := iam.NewFromConfig(s.AwsConfig)
@@ -24,6 +35,17 @@ func (i *iamOIDCProvider) DeleteResource(ctx context.Context, s *Settings, r Res
 	return err
 }
 
+// ResourceTags implements HasResourceTags.
+func (i *iamOIDCProvider) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	out, err := iam.NewFromConfig(s.AwsConfig).GetOpenIDConnectProvider(ctx, &iam.GetOpenIDConnectProviderInput{
+		OpenIDConnectProviderArn: &r.ID[0],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting oidc provider: %s", err)
+	}
+	return iamTags(out.Tags), nil
+}
+
 // Type implements Resource.
 func (i *iamOIDCProvider) Type() string {
 	return "AWS::IAM::OIDCProvider"
@@ -44,27 +66,27 @@ func (i *iamOIDCProvider) FindResources(ctx context.Context, s *Settings) ([]Res
 			continue
 		}
 
+		// the list-result is only ARNs, so we need to get the
+		// provider for its tags and creation-date.
+		getResult, err := c.GetOpenIDConnectProvider(ctx, &iam.GetOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: provider.Arn,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting oidc provider: %s", err)
+		}
+
 		var r Resource
 		r.Type = i.Type()
 		r.ID = []string{*provider.Arn}
 		r.Tags = map[string]string{}
+		r.CreatedAt = aws.ToTime(getResult.CreateDate)
 		found = append(found, r)
 
-		p := iam.NewListOpenIDConnectProviderTagsPaginator(c, &iam.ListOpenIDConnectProviderTagsInput{
-			OpenIDConnectProviderArn: provider.Arn,
-		})
-		for p.HasMorePages() {
-			tagResult, err := p.NextPage(ctx)
-			if err != nil {
-				return nil, fmt.Errorf("listing oidc provider tags: %s", err)
-			}
-
-			for _, tag := range tagResult.Tags {
-				if tag.Key == nil || tag.Value == nil {
-					continue
-				}
-				r.Tags[*tag.Key] = *tag.Value
+		for _, tag := range getResult.Tags {
+			if tag.Key == nil || tag.Value == nil {
+				continue
 			}
+			r.Tags[*tag.Key] = *tag.Value
 		}
 	}
 
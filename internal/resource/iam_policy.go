@@ -3,7 +3,10 @@ package resource
 import (
 	"context"
 	"fmt"
+	"maps"
+	"path"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/iam/types"
 )
@@ -20,7 +23,7 @@ func (i *iamPolicy) DeleteResource(ctx context.Context, s *Settings, r Resource)
 	for pvp.HasMorePages() {
 		versions, err := pvp.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("listing policy versions for %q: %s", r, err)
+			return fmt.Errorf("listing policy versions for %q: %w", r, err)
 		}
 		for _, version := range versions.Versions {
 			if version.IsDefaultVersion {
@@ -31,7 +34,7 @@ func (i *iamPolicy) DeleteResource(ctx context.Context, s *Settings, r Resource)
 				VersionId: version.VersionId,
 			})
 			if err != nil {
-				return fmt.Errorf("deleting policy version for %q: %s", r, err)
+				return fmt.Errorf("deleting policy version for %q: %w", r, err)
 			}
 		}
 	}
@@ -46,6 +49,16 @@ func (*iamPolicy) Dependencies() []string {
 	return []string{ResourceTypeIAMRole}
 }
 
+// MaxConcurrency implements HasMaxConcurrency.
+func (*iamPolicy) MaxConcurrency() int {
+	return iamMaxConcurrency
+}
+
+// ConcurrencyGroup implements HasConcurrencyGroup.
+func (*iamPolicy) ConcurrencyGroup() string {
+	return "IAM"
+}
+
 func (*iamPolicy) IsGlobal() bool {
 	return true
 }
@@ -63,10 +76,15 @@ func (*iamPolicy) FindResources(ctx context.Context, s *Settings) ([]Resource, e
 			return nil, fmt.Errorf("listing policies: %s", err)
 		}
 		for _, p := range ps.Policies {
+			if !s.NamePattern.Match(aws.ToString(p.PolicyName)) {
+				continue
+			}
+
 			var r Resource
 			r.Type = ResourceTypeIAMPolicy
 			r.ID = []string{*p.Arn}
 			r.Tags = map[string]string{}
+			r.CreatedAt = aws.ToTime(p.CreateDate)
 			result = append(result, r)
 
 			tp := iam.NewListPolicyTagsPaginator(c, &iam.ListPolicyTagsInput{
@@ -90,6 +108,28 @@ func (*iamPolicy) FindResources(ctx context.Context, s *Settings) ([]Resource, e
 	return result, nil
 }
 
+// ResourceTags implements HasResourceTags.
+func (i *iamPolicy) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	p := iam.NewListPolicyTagsPaginator(iam.NewFromConfig(s.AwsConfig), &iam.ListPolicyTagsInput{
+		PolicyArn: &r.ID[0],
+	})
+	tags := map[string]string{}
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing policy tags: %s", err)
+		}
+		maps.Copy(tags, iamTags(page.Tags))
+	}
+	return tags, nil
+}
+
+// ResourceName implements HasResourceNames. Policies are identified by
+// their ARN, which ends with the name (after the path).
+func (i *iamPolicy) ResourceName(r Resource) string {
+	return path.Base(r.ID[0])
+}
+
 // Type implements ResourceProvider.
 func (i *iamPolicy) Type() string {
 	return ResourceTypeIAMPolicy
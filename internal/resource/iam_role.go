@@ -3,12 +3,20 @@ package resource
 import (
 	"context"
 	"fmt"
+	"maps"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
 )
 
 type iamRole struct{}
 
+// iamMaxConcurrency limits deletions of all IAM resource-types together:
+// IAM throttles mutating calls at a low rate, and deleting a role or a
+// policy takes several calls.
+const iamMaxConcurrency = 4
+
 // DependentResources implements ResourceProvider.
 func (i *iamRole) DependentResources(ctx context.Context, s *Settings, r Resource) ([]Resource, error) {
 	c := iam.NewFromConfig(s.AwsConfig)
@@ -30,6 +38,25 @@ func (i *iamRole) DependentResources(ctx context.Context, s *Settings, r Resourc
 			var r Resource
 			r.Type = ResourceTypeIAMInstanceProfile
 			r.ID = []string{*profile.InstanceProfileName}
+			r.CreatedAt = aws.ToTime(profile.CreateDate)
+			r.Tags = map[string]string{}
+
+			// the listing doesn't include tags
+			tp := iam.NewListInstanceProfileTagsPaginator(c, &iam.ListInstanceProfileTagsInput{
+				InstanceProfileName: profile.InstanceProfileName,
+			})
+			for tp.HasMorePages() {
+				tags, err := tp.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("listing instance profile tags: %s", err)
+				}
+				for _, t := range tags.Tags {
+					if t.Key == nil || t.Value == nil {
+						continue
+					}
+					r.Tags[*t.Key] = *t.Value
+				}
+			}
 			result = append(result, r)
 		}
 	}
@@ -37,11 +64,44 @@ func (i *iamRole) DependentResources(ctx context.Context, s *Settings, r Resourc
 	return result, nil
 }
 
+// ResourceTags implements HasResourceTags.
+func (i *iamRole) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	p := iam.NewListRoleTagsPaginator(iam.NewFromConfig(s.AwsConfig), &iam.ListRoleTagsInput{
+		RoleName: &r.ID[0],
+	})
+	tags := map[string]string{}
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing role tags: %s", err)
+		}
+		maps.Copy(tags, iamTags(page.Tags))
+	}
+	return tags, nil
+}
+
+// iamTags converts IAM tags to a map.
+func iamTags(ts []types.Tag) map[string]string {
+	result := map[string]string{}
+	for _, t := range ts {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		result[*t.Key] = *t.Value
+	}
+	return result
+}
+
 // IsGlobal implements ResourceProvider.
 func (i *iamRole) IsGlobal() bool {
 	return true
 }
 
+// ResourceName implements HasResourceNames.
+func (i *iamRole) ResourceName(r Resource) string {
+	return r.ID[0]
+}
+
 // Type implements Resource.
 func (i *iamRole) Type() string {
 	return "AWS::IAM::Role"
@@ -64,7 +124,7 @@ func (i *iamRole) DeleteResource(ctx context.Context, s *Settings, r Resource) e
 	for rpp.HasMorePages() {
 		inlineRoles, err := rpp.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("listing role policies: %s", err)
+			return fmt.Errorf("listing role policies: %w", err)
 		}
 
 		for _, p := range inlineRoles.PolicyNames {
@@ -73,7 +133,7 @@ func (i *iamRole) DeleteResource(ctx context.Context, s *Settings, r Resource) e
 				PolicyName: &p,
 			})
 			if err != nil {
-				return fmt.Errorf("deleting role policy %q: %s", p, err)
+				return fmt.Errorf("deleting role policy %q: %w", p, err)
 			}
 		}
 	}
@@ -85,7 +145,7 @@ func (i *iamRole) DeleteResource(ctx context.Context, s *Settings, r Resource) e
 	for arpp.HasMorePages() {
 		rolePolicies, err := arpp.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("listing attached role policies: %s", err)
+			return fmt.Errorf("listing attached role policies: %w", err)
 		}
 
 		for _, p := range rolePolicies.AttachedPolicies {
@@ -97,7 +157,7 @@ func (i *iamRole) DeleteResource(ctx context.Context, s *Settings, r Resource) e
 				PolicyArn: p.PolicyArn,
 			})
 			if err != nil {
-				return fmt.Errorf("detaching role policy %q: %s", *p.PolicyArn, err)
+				return fmt.Errorf("detaching role policy %q: %w", *p.PolicyArn, err)
 			}
 		}
 	}
@@ -110,6 +170,16 @@ func (i *iamRole) DeleteResource(ctx context.Context, s *Settings, r Resource) e
 	return err
 }
 
+// MaxConcurrency implements HasMaxConcurrency.
+func (*iamRole) MaxConcurrency() int {
+	return iamMaxConcurrency
+}
+
+// ConcurrencyGroup implements HasConcurrencyGroup.
+func (*iamRole) ConcurrencyGroup() string {
+	return "IAM"
+}
+
 func (*iamRole) Dependencies() []string {
 	// wait until we're done using roles.
 	// Note! For most cleanups, deleting roles will be a
@@ -134,11 +204,15 @@ func (i *iamRole) FindResources(ctx context.Context, s *Settings) ([]Resource, e
 				// ??
 				continue
 			}
+			if !s.NamePattern.Match(*role.RoleName) {
+				continue
+			}
 
 			var r Resource
 			r.Type = i.Type()
 			r.ID = []string{*role.RoleName}
 			r.Tags = map[string]string{}
+			r.CreatedAt = aws.ToTime(role.CreateDate)
 			foundRoles = append(foundRoles, r)
 
 			rtp := iam.NewListRoleTagsPaginator(c, &iam.ListRoleTagsInput{
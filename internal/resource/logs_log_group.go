@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 )
 
@@ -19,6 +22,23 @@ func (l *logsLogGroup) DeleteResource(ctx context.Context, s *Settings, r Resour
 	return err
 }
 
+// TaggingAPIType implements HasTaggedResources.
+func (l *logsLogGroup) TaggingAPIType() string {
+	return "logs:log-group"
+}
+
+// ResourceFromARN implements HasTaggedResources.
+func (l *logsLogGroup) ResourceFromARN(a arn.ARN) (Resource, bool) {
+	// the ARN may have a trailing ":*"
+	name := strings.TrimSuffix(arnResourceID(a), ":*")
+	return Resource{Type: l.Type(), ID: []string{name}}, true
+}
+
+// ResourceName implements HasResourceNames.
+func (l *logsLogGroup) ResourceName(r Resource) string {
+	return r.ID[0]
+}
+
 // Type implements ResourceProvider.
 func (l *logsLogGroup) Type() string {
 	return ResourceTypeLogsLogGroup
@@ -28,17 +48,28 @@ func (l *logsLogGroup) FindResources(ctx context.Context, s *Settings) ([]Resour
 	var result []Resource
 
 	c := cloudwatchlogs.NewFromConfig(s.AwsConfig)
-	lgp := cloudwatchlogs.NewDescribeLogGroupsPaginator(c, &cloudwatchlogs.DescribeLogGroupsInput{})
+	input := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if prefix := s.NamePattern.Prefix(); prefix != "" {
+		input.LogGroupNamePrefix = &prefix
+	}
+	lgp := cloudwatchlogs.NewDescribeLogGroupsPaginator(c, input)
 	for lgp.HasMorePages() {
 		lgs, err := lgp.NextPage(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("describing log groups: %s", err)
 		}
 		for _, lg := range lgs.LogGroups {
+			if !s.NamePattern.Match(*lg.LogGroupName) {
+				continue
+			}
+
 			var r Resource
 			r.Type = l.Type()
 			r.ID = []string{*lg.LogGroupName}
 			r.Tags = map[string]string{}
+			if lg.CreationTime != nil {
+				r.CreatedAt = time.UnixMilli(*lg.CreationTime)
+			}
 			result = append(result, r)
 
 			tags, err := c.ListTagsForResource(ctx, &cloudwatchlogs.ListTagsForResourceInput{
@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimits maps AWS services to a limit in requests per second. Services
+// are named by their SDK service-id (for example "EC2" or "Route 53"),
+// compared case-insensitively and ignoring spaces. A limit of zero
+// disables rate-limiting for a service.
+type RateLimits map[string]float64
+
+// DefaultRateLimits returns our default limits. Where AWS documents a limit
+// we use it, otherwise we pick something conservative. The limits are shared
+// with everything else using the account, so we don't want to use all of it.
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		// https://docs.aws.amazon.com/ec2/latest/devguide/ec2-api-throttling.html
+		// (non-mutating actions refill at 20/s, mutating actions at 5/s)
+		"EC2": 20,
+		// IAM doesn't publish its limits, but it throttles quickly
+		"IAM": 10,
+		// https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/DNSLimitations.html#limits-api-requests
+		"Route 53": 5,
+		"EKS":      10,
+		// https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/cloudwatch_limits_cwl.html
+		"CloudWatch Logs": 10,
+		// https://docs.aws.amazon.com/eventbridge/latest/userguide/eb-quota.html
+		"EventBridge": 10,
+		// https://docs.aws.amazon.com/elasticloadbalancing/latest/APIReference/throttling.html
+		"Elastic Load Balancing v2": 10,
+		"SQS":                       50,
+	}
+}
+
+// Merge returns a copy of the limits with the overrides applied.
+func (l RateLimits) Merge(overrides RateLimits) RateLimits {
+	result := RateLimits{}
+	for svc, rps := range l {
+		result[normalizeServiceID(svc)] = rps
+	}
+	for svc, rps := range overrides {
+		result[normalizeServiceID(svc)] = rps
+	}
+	return result
+}
+
+// normalizeServiceID lets users spell "Route 53" as "route53".
+func normalizeServiceID(id string) string {
+	return strings.ToLower(strings.ReplaceAll(id, " ", ""))
+}
+
+// WithRateLimits adds client-side rate-limiting to an AWS config. Every
+// client created from the config shares the same limits, so discovery and
+// deletion stay under the limits together.
+func WithRateLimits(cfg *aws.Config, limits RateLimits) {
+	limiters := map[string]*rate.Limiter{}
+	for svc, rps := range limits {
+		if rps <= 0 {
+			continue
+		}
+		burst := max(1, int(math.Ceil(rps)))
+		limiters[normalizeServiceID(svc)] = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+
+	mw := middleware.FinalizeMiddlewareFunc("RateLimit", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		l, ok := limiters[normalizeServiceID(awsmiddleware.GetServiceID(ctx))]
+		if ok {
+			err := l.Wait(ctx)
+			if err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+		}
+		return next.HandleFinalize(ctx, in)
+	})
+
+	// the finalize step runs for every attempt (it comes after the
+	// SDK's retry middleware), so retries are limited as well.
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(mw, middleware.After)
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aslatter/aws-project-scrub/internal/filter"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 )
 
 type Settings struct {
@@ -13,10 +15,17 @@ type Settings struct {
 	Region    string
 	Partition string
 	Account   string
-	Filter    struct {
-		TagKey   string
-		TagValue string
-	}
+	// Filter selects the resources to delete. Providers may use it to
+	// narrow their searches.
+	Filter *filter.Expr
+	// NamePattern, if set, limits root resources to those whose names
+	// match, for providers which implement HasResourceNames. Providers
+	// may use it to narrow their searches.
+	NamePattern *filter.Glob
+
+	// TaggingAPI is used to find root resources for providers which
+	// support it. May be nil.
+	TaggingAPI *TaggingAPI
 }
 
 type ResourceProvider interface {
@@ -27,13 +36,15 @@ type ResourceProvider interface {
 type HasRootResources interface {
 	// FindResources discovers "root" resources which must be deleted. returned resources
 	// must have the 'Tags' property filled in correctly or the resources
-	// will be ignored.
+	// will be ignored. 'CreatedAt' should be filled in if the API reports it.
 	FindResources(ctx context.Context, s *Settings) ([]Resource, error)
 }
 
 type HasDependentResources interface {
 	// DependentResources discovers resources which must be deleted prior to deleting a
-	// specific resource.
+	// specific resource. Returned resources should have their 'Tags' filled in if the
+	// API reports them, so protected resources can be recognized. Otherwise they are
+	// looked up for providers which implement HasResourceTags.
 	DependentResources(ctx context.Context, s *Settings, r Resource) ([]Resource, error)
 }
 
@@ -45,6 +56,49 @@ type IsGlobal interface {
 	IsGlobal() bool
 }
 
+type HasMaxConcurrency interface {
+	// MaxConcurrency limits how many resources of this provider's type are
+	// deleted at the same time. This is useful for APIs which throttle
+	// aggressively.
+	MaxConcurrency() int
+}
+
+type HasConcurrencyGroup interface {
+	// ConcurrencyGroup names a MaxConcurrency limit shared with other
+	// providers, for APIs which throttle across resource-types.
+	ConcurrencyGroup() string
+}
+
+type HasResourceLookup interface {
+	// LookupResource returns the resource for an identifier used outside
+	// of this program, such as a CloudFormation physical-ID. Providers
+	// whose resource-IDs are a single AWS identifier don't need this.
+	LookupResource(ctx context.Context, s *Settings, id string) (Resource, error)
+}
+
+type HasResourceTags interface {
+	// ResourceTags looks up the tags of a resource which was found
+	// without them, such as in a CloudFormation stack or as a dependent
+	// resource. For resources found in other ways, EC2 resources and
+	// those of providers implementing HasTaggedResources don't need this.
+	ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error)
+}
+
+type HasTaggedResources interface {
+	// TaggingAPIType is the resource-type used by the resource groups
+	// tagging API for this provider's resources, such as "ec2:vpc".
+	TaggingAPIType() string
+	// ResourceFromARN converts an ARN returned by the tagging API into a
+	// resource. It returns false for ARNs the provider doesn't handle.
+	ResourceFromARN(a arn.ARN) (Resource, bool)
+}
+
+type HasResourceNames interface {
+	// ResourceName returns the name of one of the provider's resources,
+	// to match against Settings.NamePattern.
+	ResourceName(r Resource) string
+}
+
 var registry [](func(*Settings) ResourceProvider) = [](func(*Settings) ResourceProvider){}
 
 func register(fn func(*Settings) ResourceProvider) {
@@ -63,6 +117,18 @@ type Resource struct {
 	Type string
 	ID   []string
 	Tags map[string]string
+	// CreatedAt is when the resource was created, if the provider
+	// knows. It is the zero time otherwise.
+	CreatedAt time.Time
+}
+
+// LookupResource returns a provider's resource for an identifier used
+// outside of this program, such as a CloudFormation physical-ID.
+func LookupResource(ctx context.Context, s *Settings, p ResourceProvider, id string) (Resource, error) {
+	if l, ok := p.(HasResourceLookup); ok {
+		return l.LookupResource(ctx, s, id)
+	}
+	return Resource{Type: p.Type(), ID: []string{id}}, nil
 }
 
 func (r Resource) String() string {
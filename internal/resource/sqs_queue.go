@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"path"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 type sqsQueue struct{}
@@ -23,18 +27,25 @@ func (*sqsQueue) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 	var result []Resource
 
 	c := sqs.NewFromConfig(s.AwsConfig)
-	p := sqs.NewListQueuesPaginator(c, &sqs.ListQueuesInput{})
+	input := &sqs.ListQueuesInput{}
+	if prefix := s.NamePattern.Prefix(); prefix != "" {
+		input.QueueNamePrefix = &prefix
+	}
+	p := sqs.NewListQueuesPaginator(c, input)
 	for p.HasMorePages() {
 		qs, err := p.NextPage(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("listing queues: %s", err)
 		}
 		for _, qUrl := range qs.QueueUrls {
+			if !s.NamePattern.Match(sqsQueueName(qUrl)) {
+				continue
+			}
+
 			var r Resource
 			r.Type = ResourceTypeSQSQueue
 			r.ID = []string{qUrl}
 			r.Tags = map[string]string{}
-			result = append(result, r)
 
 			ts, err := c.ListQueueTags(ctx, &sqs.ListQueueTagsInput{
 				QueueUrl: &qUrl,
@@ -43,12 +54,55 @@ func (*sqsQueue) FindResources(ctx context.Context, s *Settings) ([]Resource, er
 				return nil, fmt.Errorf("listing queue tags: %s", err)
 			}
 			maps.Copy(r.Tags, ts.Tags)
+
+			// there's no listing queues with their attributes, so
+			// we only look up the creation-time of queues which
+			// may be selected
+			if s.Filter != nil && !s.Filter.Match(r.Tags) {
+				continue
+			}
+			as, err := c.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+				QueueUrl:       &qUrl,
+				AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameCreatedTimestamp},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("getting queue attributes: %s", err)
+			}
+			// seconds since the epoch
+			if created, err := strconv.ParseInt(as.Attributes[string(types.QueueAttributeNameCreatedTimestamp)], 10, 64); err == nil {
+				r.CreatedAt = time.Unix(created, 0)
+			}
+			result = append(result, r)
 		}
 	}
 
 	return result, nil
 }
 
+// ResourceTags implements HasResourceTags.
+func (*sqsQueue) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	ts, err := sqs.NewFromConfig(s.AwsConfig).ListQueueTags(ctx, &sqs.ListQueueTagsInput{
+		QueueUrl: &r.ID[0],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing queue tags: %s", err)
+	}
+	tags := map[string]string{}
+	maps.Copy(tags, ts.Tags)
+	return tags, nil
+}
+
+// ResourceName implements HasResourceNames.
+func (*sqsQueue) ResourceName(r Resource) string {
+	return sqsQueueName(r.ID[0])
+}
+
+// sqsQueueName returns the name of a queue from its URL, which ends
+// with the name.
+func sqsQueueName(queueURL string) string {
+	return path.Base(queueURL)
+}
+
 // Type implements ResourceProvider.
 func (s *sqsQueue) Type() string {
 	return ResourceTypeSQSQueue
@@ -0,0 +1,162 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	tagging "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// TaggingAPI finds root resources with the resource groups tagging API. A
+// single search covers every provider which supports it, which is a lot
+// fewer calls than listing every resource and looking up its tags.
+type TaggingAPI struct {
+	providers []HasTaggedResources
+
+	once sync.Once
+	// found resources, by provider-type
+	found map[string][]Resource
+	err   error
+}
+
+// NewTaggingAPI returns a TaggingAPI searching for the resources of the
+// passed-in providers (those which support it).
+func NewTaggingAPI(providers []ResourceProvider) *TaggingAPI {
+	var t TaggingAPI
+	for _, p := range providers {
+		if tp, ok := p.(HasTaggedResources); ok {
+			t.providers = append(t.providers, tp)
+		}
+	}
+	return &t
+}
+
+// FindRootResources finds the root resources of a provider. If the settings
+// have a tagging API and the provider supports it, it is used instead of the
+// provider's own search. With a name-pattern, only resources of providers
+// implementing HasResourceNames are returned, and only if their names match.
+func FindRootResources(ctx context.Context, s *Settings, p HasRootResources) ([]Resource, error) {
+	np, hasNames := p.(HasResourceNames)
+	if s.NamePattern != nil && !hasNames {
+		return nil, nil
+	}
+
+	var rs []Resource
+	var err error
+	if tp, ok := p.(HasTaggedResources); ok && s.TaggingAPI != nil {
+		rs, err = s.TaggingAPI.find(ctx, s, tp)
+	} else {
+		rs, err = p.FindResources(ctx, s)
+	}
+	if err != nil || s.NamePattern == nil {
+		return rs, err
+	}
+
+	var result []Resource
+	for _, r := range rs {
+		if s.NamePattern.Match(np.ResourceName(r)) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (t *TaggingAPI) find(ctx context.Context, s *Settings, p HasTaggedResources) ([]Resource, error) {
+	t.once.Do(func() {
+		t.err = t.search(ctx, s)
+	})
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.found[p.(ResourceProvider).Type()], nil
+}
+
+func (t *TaggingAPI) search(ctx context.Context, s *Settings) error {
+	t.found = map[string][]Resource{}
+
+	byTaggingType := map[string]HasTaggedResources{}
+	for _, p := range t.providers {
+		byTaggingType[p.TaggingAPIType()] = p
+	}
+	typeFilters := slices.Sorted(maps.Keys(byTaggingType))
+
+	// the API can only filter on keys and literal values. The full
+	// filter is still evaluated against what we find.
+	var tagFilters []types.TagFilter
+	for _, term := range s.Filter.RequiredTerms() {
+		tf := types.TagFilter{Key: &term.Key}
+		if !term.Exists && !term.Glob {
+			tf.Values = []string{term.Value}
+		}
+		tagFilters = append(tagFilters, tf)
+	}
+
+	c := tagging.NewFromConfig(s.AwsConfig)
+
+	// the API takes at most 100 resource-types at a time
+	for chunk := range slices.Chunk(typeFilters, 100) {
+		p := tagging.NewGetResourcesPaginator(c, &tagging.GetResourcesInput{
+			ResourceTypeFilters: chunk,
+			TagFilters:          tagFilters,
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("getting tagged resources: %s", err)
+			}
+			for _, m := range page.ResourceTagMappingList {
+				if m.ResourceARN == nil {
+					continue
+				}
+				a, err := arn.Parse(*m.ResourceARN)
+				if err != nil {
+					return fmt.Errorf("parsing ARN %q: %s", *m.ResourceARN, err)
+				}
+
+				provider, ok := byTaggingType[taggingType(a)]
+				if !ok {
+					continue
+				}
+				r, ok := provider.ResourceFromARN(a)
+				if !ok {
+					continue
+				}
+				r.Tags = map[string]string{}
+				for _, tag := range m.Tags {
+					if tag.Key == nil || tag.Value == nil {
+						continue
+					}
+					r.Tags[*tag.Key] = *tag.Value
+				}
+				t.found[r.Type] = append(t.found[r.Type], r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// taggingType returns the tagging API resource-type of an ARN, such as
+// "ec2:vpc" for "arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0123".
+func taggingType(a arn.ARN) string {
+	typ := a.Resource
+	if i := strings.IndexAny(typ, "/:"); i >= 0 {
+		typ = typ[:i]
+	}
+	return a.Service + ":" + typ
+}
+
+// arnResourceID returns the part of an ARN's resource after the
+// resource-type, such as "vpc-0123" for "vpc/vpc-0123".
+func arnResourceID(a arn.ARN) string {
+	if i := strings.IndexAny(a.Resource, "/:"); i >= 0 {
+		return a.Resource[i+1:]
+	}
+	return a.Resource
+}
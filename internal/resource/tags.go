@@ -0,0 +1,158 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	tagging "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// ec2TagBatch is the most resource-IDs we pass to DescribeTags at once.
+const ec2TagBatch = 200
+
+// AddTags looks up the tags of resources which weren't found by searching
+// for them (those in a CloudFormation stack, for example), so they can be
+// checked for protection like any other. Resources which already have
+// tags are left alone. It fails if the tags of a resource can't be found
+// out, rather than treating it as untagged.
+func AddTags(ctx context.Context, s *Settings, providers []ResourceProvider, rs []Resource) error {
+	byType := map[string]ResourceProvider{}
+	for _, p := range providers {
+		byType[p.Type()] = p
+	}
+
+	ec2IDs := map[string]*Resource{}
+	byTaggingType := map[string]HasTaggedResources{}
+	tagged := map[string]*Resource{}
+
+	for i := range rs {
+		r := &rs[i]
+		if r.Tags != nil {
+			continue
+		}
+		p := byType[r.Type]
+		if tp, ok := p.(HasResourceTags); ok {
+			tags, err := tp.ResourceTags(ctx, s, *r)
+			if err != nil {
+				return fmt.Errorf("looking up tags of %s: %s", r, err)
+			}
+			r.Tags = tags
+			continue
+		}
+
+		r.Tags = map[string]string{}
+		if strings.HasPrefix(r.Type, "AWS::EC2::") {
+			// the last part of the ID is the EC2 resource-ID
+			ec2IDs[r.ID[len(r.ID)-1]] = r
+			continue
+		}
+		if tp, ok := p.(HasTaggedResources); ok {
+			byTaggingType[tp.TaggingAPIType()] = tp
+			tagged[r.String()] = r
+			continue
+		}
+		return fmt.Errorf("can't look up tags of %s", r)
+	}
+
+	if len(ec2IDs) > 0 {
+		err := addEC2Tags(ctx, s, ec2IDs)
+		if err != nil {
+			return err
+		}
+	}
+	if len(tagged) > 0 {
+		err := addTaggingAPITags(ctx, s, byTaggingType, tagged)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addEC2Tags looks up the tags of EC2 resources, by EC2 resource-ID.
+func addEC2Tags(ctx context.Context, s *Settings, byID map[string]*Resource) error {
+	c := ec2.NewFromConfig(s.AwsConfig)
+
+	ids := slices.Sorted(maps.Keys(byID))
+	for batch := range slices.Chunk(ids, ec2TagBatch) {
+		p := ec2.NewDescribeTagsPaginator(c, &ec2.DescribeTagsInput{
+			Filters: []ec2types.Filter{{
+				Name:   aws.String("resource-id"),
+				Values: batch,
+			}},
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("describing tags: %s", err)
+			}
+			for _, t := range page.Tags {
+				if t.ResourceId == nil || t.Key == nil || t.Value == nil {
+					continue
+				}
+				if r, ok := byID[*t.ResourceId]; ok {
+					r.Tags[*t.Key] = *t.Value
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addTaggingAPITags looks up the tags of resources (by their String form)
+// with the tagging API. It only lists resources which have tags, so
+// resources it doesn't list have none.
+func addTaggingAPITags(ctx context.Context, s *Settings, byTaggingType map[string]HasTaggedResources, rs map[string]*Resource) error {
+	c := tagging.NewFromConfig(s.AwsConfig)
+
+	typeFilters := slices.Sorted(maps.Keys(byTaggingType))
+	for chunk := range slices.Chunk(typeFilters, 100) {
+		p := tagging.NewGetResourcesPaginator(c, &tagging.GetResourcesInput{
+			ResourceTypeFilters: chunk,
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("getting tagged resources: %s", err)
+			}
+			for _, m := range page.ResourceTagMappingList {
+				if m.ResourceARN == nil {
+					continue
+				}
+				a, err := arn.Parse(*m.ResourceARN)
+				if err != nil {
+					return fmt.Errorf("parsing ARN %q: %s", *m.ResourceARN, err)
+				}
+				provider, ok := byTaggingType[taggingType(a)]
+				if !ok {
+					continue
+				}
+				found, ok := provider.ResourceFromARN(a)
+				if !ok {
+					continue
+				}
+				r, ok := rs[found.String()]
+				if !ok {
+					continue
+				}
+				for _, tag := range m.Tags {
+					if tag.Key == nil || tag.Value == nil {
+						continue
+					}
+					r.Tags[*tag.Key] = *tag.Value
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// terraformType describes how to find our identifier for a Terraform
+// resource-type.
+type terraformType struct {
+	typ string
+	// attribute holding an identifier LookupResource understands
+	attribute string
+}
+
+// terraformTypes maps Terraform AWS-provider resource-types to our own.
+var terraformTypes = map[string]terraformType{
+	"aws_vpc":                          {ResourceTypeEC2VPC, "id"},
+	"aws_subnet":                       {ResourceTypeEC2Subnet, "id"},
+	"aws_security_group":               {ResourceTypeEC2SecurityGroup, "id"},
+	"aws_instance":                     {ResourceTypeEC2Instance, "id"},
+	"aws_nat_gateway":                  {ResourceTypeEC2NATGateway, "id"},
+	"aws_eip":                          {ResourceTypeEC2EIP, "allocation_id"},
+	"aws_ebs_volume":                   {ResourceTypeEC2Volume, "id"},
+	"aws_launch_template":              {ResourceTypeEC2LaunchTemplate, "id"},
+	"aws_network_acl":                  {ResourceTypeEC2NetworkACL, "id"},
+	"aws_route_table":                  {ResourceTypeEC2RouteTable, "id"},
+	"aws_internet_gateway":             {ResourceTypeEC2InternetGateway, "id"},
+	"aws_egress_only_internet_gateway": {ResourceTypeEC2EgressOnlyInternetGateway, "id"},
+	"aws_vpc_endpoint":                 {ResourceTypeEC2VPCEndpoint, "id"},
+	"aws_lb":                           {ResourceTypeLoadBalancer, "arn"},
+	"aws_alb":                          {ResourceTypeLoadBalancer, "arn"},
+	"aws_lb_target_group":              {ResourceTypeLoadBalancerTargetGroup, "arn"},
+	"aws_alb_target_group":             {ResourceTypeLoadBalancerTargetGroup, "arn"},
+	"aws_eks_cluster":                  {ResourceTypeEKSCluster, "name"},
+	"aws_eks_node_group":               {ResourceTypeEKSNodegroup, "arn"},
+	"aws_eks_fargate_profile":          {ResourceTypeEKSFargateProfile, "arn"},
+	"aws_eks_pod_identity_association": {ResourceTypeEKSPodIdentityAssociation, "association_arn"},
+	"aws_cloudwatch_event_rule":        {ResourceTypeEventsRule, "name"},
+	"aws_cloudwatch_log_group":         {ResourceTypeLogsLogGroup, "name"},
+	"aws_iam_role":                     {ResourceTypeIAMRole, "name"},
+	"aws_iam_policy":                   {ResourceTypeIAMPolicy, "arn"},
+	"aws_iam_instance_profile":         {ResourceTypeIAMInstanceProfile, "name"},
+	"aws_iam_openid_connect_provider":  {"AWS::IAM::OIDCProvider", "arn"},
+	"aws_route53_zone":                 {"AWS::Route53::HostedZone", "zone_id"},
+	"aws_sqs_queue":                    {ResourceTypeSQSQueue, "url"},
+}
+
+// terraformState is the part of a Terraform state file (version 4)
+// we need.
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// FindTerraformResources returns the resources recorded in a Terraform
+// state file, for the resource-types we have providers for. It also
+// returns descriptions of the managed AWS resources we can't handle.
+// Tags come from the state, or are looked up if it has none recorded.
+func FindTerraformResources(ctx context.Context, s *Settings, providers []ResourceProvider, state io.Reader) ([]Resource, []string, error) {
+	var st terraformState
+	err := json.NewDecoder(state).Decode(&st)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding state: %s", err)
+	}
+	if st.Version != 4 {
+		return nil, nil, fmt.Errorf("unsupported state version %d (expected 4)", st.Version)
+	}
+
+	byType := map[string]ResourceProvider{}
+	for _, p := range providers {
+		byType[p.Type()] = p
+	}
+
+	var found []Resource
+	var skipped []string
+
+	for _, tr := range st.Resources {
+		if tr.Mode != "managed" || !strings.HasPrefix(tr.Type, "aws_") {
+			continue
+		}
+		addr := tr.Type + "." + tr.Name
+		if tr.Module != "" {
+			addr = tr.Module + "." + addr
+		}
+
+		tt, ok := terraformTypes[tr.Type]
+		if !ok {
+			skipped = append(skipped, addr+" (unsupported type)")
+			continue
+		}
+		provider, ok := byType[tt.typ]
+		if !ok {
+			// global types, outside the global region
+			skipped = append(skipped, addr+" (not handled in this region)")
+			continue
+		}
+
+		for _, inst := range tr.Instances {
+			id, _ := inst.Attributes[tt.attribute].(string)
+			if id == "" {
+				skipped = append(skipped, addr+" (no "+tt.attribute+")")
+				continue
+			}
+			if reason := terraformSkipReason(s, inst.Attributes); reason != "" {
+				skipped = append(skipped, addr+" ("+reason+")")
+				continue
+			}
+
+			r, err := LookupResource(ctx, s, provider, id)
+			if err != nil {
+				return nil, nil, fmt.Errorf("looking up %s %s: %s", addr, id, err)
+			}
+			r.Tags = terraformTags(inst.Attributes)
+			found = append(found, r)
+		}
+	}
+
+	err = AddTags(ctx, s, providers, found)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return found, skipped, nil
+}
+
+// terraformTags returns the tags recorded in a resource's state, or nil
+// if there are none. "tags_all" includes the provider's default tags, but
+// older states only have "tags".
+func terraformTags(attrs map[string]any) map[string]string {
+	for _, attr := range []string{"tags_all", "tags"} {
+		m, ok := attrs[attr].(map[string]any)
+		if !ok {
+			continue
+		}
+		tags := map[string]string{}
+		for k, v := range m {
+			if v, ok := v.(string); ok {
+				tags[k] = v
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// terraformSkipReason explains why a resource from a state file isn't
+// ours to delete (because it's in another region, for example), or
+// returns "".
+func terraformSkipReason(s *Settings, attrs map[string]any) string {
+	// rules on other event-buses are named "bus/rule", which
+	// our provider doesn't handle
+	if bus, _ := attrs["event_bus_name"].(string); bus != "" && bus != "default" {
+		return "event bus " + bus
+	}
+
+	v, _ := attrs["arn"].(string)
+	a, err := arn.Parse(v)
+	if err != nil {
+		return ""
+	}
+	if a.Region != "" && a.Region != s.Region {
+		return "in region " + a.Region
+	}
+	if a.AccountID != "" && a.AccountID != s.Account {
+		return "in account " + a.AccountID
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package resource
+
+import (
+	"context"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeProvider is a provider for a resource-type, which looks up
+// tags from a fixed list.
+type fakeProvider struct {
+	typ  string
+	tags map[string]map[string]string
+}
+
+func (p *fakeProvider) Type() string {
+	return p.typ
+}
+
+func (p *fakeProvider) DeleteResource(ctx context.Context, s *Settings, r Resource) error {
+	return nil
+}
+
+func (p *fakeProvider) ResourceTags(ctx context.Context, s *Settings, r Resource) (map[string]string, error) {
+	return p.tags[r.String()], nil
+}
+
+func TestFindTerraformResources(t *testing.T) {
+	f, err := os.Open("testdata/terraform.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := &Settings{Region: "us-east-2", Account: "123456789012"}
+	var providers []ResourceProvider
+	for _, typ := range []string{
+		ResourceTypeEC2VPC,
+		ResourceTypeEC2Subnet,
+		ResourceTypeEC2Instance,
+		ResourceTypeLoadBalancer,
+		ResourceTypeLoadBalancerTargetGroup,
+		ResourceTypeEventsRule,
+	} {
+		providers = append(providers, &fakeProvider{typ: typ})
+	}
+	providers = append(providers, &fakeProvider{
+		typ: ResourceTypeLogsLogGroup,
+		tags: map[string]map[string]string{
+			ResourceTypeLogsLogGroup + "//foo/app": {"project": "foo"},
+		},
+	})
+
+	found, skipped, err := FindTerraformResources(context.Background(), s, providers, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]string{
+		// tags_all, over tags
+		ResourceTypeEC2VPC + "/vpc-1": {"project": "foo", "managed-by": "terraform"},
+		// only tags
+		ResourceTypeEC2Subnet + "/subnet-1": {"project": "foo", "tier": "private"},
+		// no tags, as opposed to unknown tags
+		ResourceTypeEC2Subnet + "/subnet-2": {},
+		ResourceTypeLoadBalancer + "/arn:aws:elasticloadbalancing:us-east-2:123456789012:loadbalancer/app/web/1": {"project": "foo"},
+		// not in the state, so looked up
+		ResourceTypeLogsLogGroup + "//foo/app": {"project": "foo"},
+	}
+	got := map[string]map[string]string{}
+	for _, r := range found {
+		got[r.String()] = r.Tags
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("found resources:\n%v\nwant:\n%v", got, want)
+	}
+	if len(found) != len(want) {
+		t.Errorf("expected %d resources, got %d: %v", len(want), len(found), slices.Collect(maps.Keys(got)))
+	}
+
+	wantSkipped := []string{
+		"aws_instance.pending (no id)",
+		"aws_s3_bucket.logs (unsupported type)",
+		"aws_iam_role.nodes (not handled in this region)",
+		"module.west.aws_lb.web (in region us-west-2)",
+		"aws_lb_target_group.shared (in account 999999999999)",
+		"aws_cloudwatch_event_rule.nightly (event bus orders)",
+	}
+	if !slices.Equal(skipped, wantSkipped) {
+		t.Errorf("skipped:\n%s\nwant:\n%s", strings.Join(skipped, "\n"), strings.Join(wantSkipped, "\n"))
+	}
+}
+
+func TestFindTerraformResourcesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string
+		wantErr string
+	}{
+		{"old version", `{"version": 3, "modules": []}`, "unsupported state version 3 (expected 4)"},
+		{"no version", `{"resources": []}`, "unsupported state version 0 (expected 4)"},
+		{"truncated", `{"version": 4, "resources": [`, "decoding state"},
+		{"not a state", `terraform { backend "s3" {} }`, "decoding state"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FindTerraformResources(context.Background(), &Settings{}, nil, strings.NewReader(tt.state))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
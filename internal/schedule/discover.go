@@ -0,0 +1,194 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+const defaultDiscoveryConcurrency = 10
+
+// discover finds root resources and their dependent resources, and
+// builds up the resource-level dependency graph. Lookups run in parallel,
+// but root resources are added in provider-order so the result does not
+// depend on which lookups finish first.
+func (p *Plan) discover(ctx context.Context) error {
+	limit := p.DiscoveryConcurrency
+	if limit <= 0 {
+		limit = defaultDiscoveryConcurrency
+	}
+
+	// find root resources
+	var rootResults [][]resource.Resource
+	if p.Roots != nil {
+		rs, err := p.Roots(ctx)
+		if err != nil {
+			return fmt.Errorf("finding root resources: %s", err)
+		}
+		rootResults = [][]resource.Resource{rs}
+	} else {
+		var err error
+		rootResults, err = p.findRoots(ctx, limit)
+		if err != nil {
+			return err
+		}
+	}
+
+	var roots []resource.Resource
+	for _, rs := range rootResults {
+		for _, r := range rs {
+			isNew, err := p.addOneResource(r)
+			if err != nil {
+				return fmt.Errorf("adding resource %q: %s", r, err)
+			}
+			if isNew {
+				roots = append(roots, r)
+			}
+		}
+	}
+
+	// find dependent resources. Every newly-found resource may have
+	// more dependent resources, so lookups fan out from here. We can't
+	// limit the errgroup itself (goroutines schedule more goroutines), so
+	// the lookups are limited instead.
+	lookups := semaphore.NewWeighted(int64(limit))
+	g, gctx := errgroup.WithContext(ctx)
+	var addDependents func(r resource.Resource) error
+	addDependents = func(r resource.Resource) error {
+		depProvider, ok := p.providers[r.Type].(resource.HasDependentResources)
+		if !ok {
+			return nil
+		}
+		// we don't need to clear the way for resources we're
+		// not deleting
+		if p.isRetained(r.String()) {
+			return nil
+		}
+
+		err := lookups.Acquire(gctx, 1)
+		if err != nil {
+			return err
+		}
+		moreResources, err := depProvider.DependentResources(gctx, p.Settings, r)
+		if err == nil {
+			err = p.addTags(gctx, moreResources)
+		}
+		lookups.Release(1)
+		if err != nil {
+			return fmt.Errorf("looking up dependent resources for %q: %s", r, err)
+		}
+
+		for _, nextResource := range moreResources {
+			isNew, err := p.addOneResource(nextResource)
+			if err != nil {
+				return fmt.Errorf("adding dependent resource %q: %s", nextResource, err)
+			}
+			if isNew {
+				g.Go(func() error {
+					return addDependents(nextResource)
+				})
+			}
+
+			err = p.addDependency(nextResource.String(), r.String())
+			if err != nil {
+				return fmt.Errorf("adding dependency on %q from %q: %s", nextResource, r, err)
+			}
+		}
+		return nil
+	}
+	for _, r := range roots {
+		g.Go(func() error {
+			return addDependents(r)
+		})
+	}
+	return g.Wait()
+}
+
+// addTags looks up the tags of resources which were found without them,
+// for providers which can, so protected resources are recognized.
+func (p *Plan) addTags(ctx context.Context, rs []resource.Resource) error {
+	for i, r := range rs {
+		if r.Tags != nil {
+			continue
+		}
+		tp, ok := p.providers[r.Type].(resource.HasResourceTags)
+		if !ok {
+			continue
+		}
+		tags, err := tp.ResourceTags(ctx, p.Settings, r)
+		if err != nil {
+			return fmt.Errorf("looking up tags of %q: %s", r, err)
+		}
+		rs[i].Tags = tags
+	}
+	return nil
+}
+
+// findRoots asks the providers for their root resources. Lookups run in
+// parallel, and the results are in provider-order.
+func (p *Plan) findRoots(ctx context.Context, limit int) ([][]resource.Resource, error) {
+	rootResults := make([][]resource.Resource, len(p.Providers))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for i, pr := range p.Providers {
+		finder, ok := pr.(resource.HasRootResources)
+		if !ok || !p.includesType(pr.Type()) {
+			continue
+		}
+		g.Go(func() error {
+			p.observer.DiscoveryStarted(pr.Type())
+			rs, err := resource.FindRootResources(gctx, p.Settings, finder)
+			if err != nil {
+				p.observer.DiscoveryFinished(pr.Type(), 0, err)
+				return fmt.Errorf("finding root resources for %q: %s", pr.Type(), err)
+			}
+			for _, r := range rs {
+				if p.Filter(r) {
+					rootResults[i] = append(rootResults[i], r)
+				}
+			}
+			p.observer.DiscoveryFinished(pr.Type(), len(rootResults[i]), nil)
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return rootResults, nil
+}
+
+// addOneResource adds a resource to the plan, and reports if the resource
+// was not already present. It is safe to call concurrently.
+func (p *Plan) addOneResource(r resource.Resource) (bool, error) {
+	if _, ok := p.providers[r.Type]; !ok {
+		return false, fmt.Errorf("unknown provider-id for resource %q: %s", r, r.Type)
+	}
+
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+
+	key := r.String()
+	if _, ok := p.resources[key]; ok {
+		// done!
+		return false, nil
+	}
+	p.resources[key] = r
+	err := p.deps.AddVertexByID(key, key)
+	if err != nil {
+		return false, fmt.Errorf("adding resource to dependency graph: %s", err)
+	}
+
+	switch {
+	case p.Retain != nil && p.Retain(r) != "":
+		p.retained[key] = p.Retain(r)
+	case !p.includesType(r.Type):
+		p.retained[key] = "type " + r.Type + " is excluded"
+	}
+
+	return true, nil
+}
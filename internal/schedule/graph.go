@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// graph is the dependency graph of a snapshot, with a short id for
+// every node. Edges point from a dependency to the thing which waits
+// on it.
+type graph struct {
+	providers     []graphNode
+	providerEdges [][2]string
+	resources     []graphNode
+	resourceEdges [][2]string
+}
+
+type graphNode struct {
+	id    string
+	label []string
+}
+
+func (s *Snapshot) graph() *graph {
+	var g graph
+
+	providerIDs := map[string]string{}
+	for i, pr := range s.Providers {
+		id := fmt.Sprintf("p%d", i)
+		providerIDs[pr.Type] = id
+		g.providers = append(g.providers, graphNode{id: id, label: []string{pr.Type}})
+	}
+	for _, pr := range s.Providers {
+		for _, dep := range pr.DependsOn {
+			depID, ok := providerIDs[dep]
+			if !ok {
+				continue
+			}
+			g.providerEdges = append(g.providerEdges, [2]string{depID, providerIDs[pr.Type]})
+		}
+	}
+
+	resourceIDs := map[string]string{}
+	for i, sr := range s.Resources {
+		r := sr.Resource()
+		id := fmt.Sprintf("r%d", i)
+		resourceIDs[r.String()] = id
+
+		label := []string{r.String()}
+		if name := r.Tags["Name"]; name != "" {
+			label = append(label, name)
+		}
+		if sr.Retained != "" {
+			label = append(label, "not deleted: "+sr.Retained)
+		}
+		g.resources = append(g.resources, graphNode{id: id, label: label})
+	}
+	for _, sr := range s.Resources {
+		id := resourceIDs[sr.Resource().String()]
+		for _, dep := range sr.DependsOn {
+			depID, ok := resourceIDs[dep]
+			if !ok {
+				continue
+			}
+			g.resourceEdges = append(g.resourceEdges, [2]string{depID, id})
+		}
+	}
+
+	return &g
+}
+
+// WriteDOT writes the provider-level and resource-level dependency graphs
+// in Graphviz DOT format.
+func (s *Snapshot) WriteDOT(w io.Writer) error {
+	g := s.graph()
+	bw := bufio.NewWriter(w)
+
+	dotQuote := func(lines []string) string {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		for i, l := range lines {
+			lines[i] = r.Replace(l)
+		}
+		return `"` + strings.Join(lines, `\n`) + `"`
+	}
+
+	fmt.Fprintln(bw, "digraph scrub {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+
+	fmt.Fprintln(bw, "  subgraph cluster_providers {")
+	fmt.Fprintln(bw, `    label="providers";`)
+	for _, n := range g.providers {
+		fmt.Fprintf(bw, "    %s [label=%s];\n", n.id, dotQuote(n.label))
+	}
+	for _, e := range g.providerEdges {
+		fmt.Fprintf(bw, "    %s -> %s;\n", e[0], e[1])
+	}
+	fmt.Fprintln(bw, "  }")
+
+	fmt.Fprintln(bw, "  subgraph cluster_resources {")
+	fmt.Fprintln(bw, `    label="resources";`)
+	for _, n := range g.resources {
+		fmt.Fprintf(bw, "    %s [label=%s];\n", n.id, dotQuote(n.label))
+	}
+	for _, e := range g.resourceEdges {
+		fmt.Fprintf(bw, "    %s -> %s;\n", e[0], e[1])
+	}
+	fmt.Fprintln(bw, "  }")
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes the provider-level and resource-level dependency
+// graphs as a Mermaid flowchart.
+func (s *Snapshot) WriteMermaid(w io.Writer) error {
+	g := s.graph()
+	bw := bufio.NewWriter(w)
+
+	mermaidQuote := func(lines []string) string {
+		r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+		for i, l := range lines {
+			lines[i] = r.Replace(l)
+		}
+		return `"` + strings.Join(lines, "<br/>") + `"`
+	}
+
+	fmt.Fprintln(bw, "flowchart LR")
+
+	fmt.Fprintln(bw, "  subgraph providers")
+	for _, n := range g.providers {
+		fmt.Fprintf(bw, "    %s[%s]\n", n.id, mermaidQuote(n.label))
+	}
+	fmt.Fprintln(bw, "  end")
+	for _, e := range g.providerEdges {
+		fmt.Fprintf(bw, "  %s --> %s\n", e[0], e[1])
+	}
+
+	fmt.Fprintln(bw, "  subgraph resources")
+	for _, n := range g.resources {
+		fmt.Fprintf(bw, "    %s[%s]\n", n.id, mermaidQuote(n.label))
+	}
+	fmt.Fprintln(bw, "  end")
+	for _, e := range g.resourceEdges {
+		fmt.Fprintf(bw, "  %s --> %s\n", e[0], e[1])
+	}
+
+	return bw.Flush()
+}
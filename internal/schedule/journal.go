@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// journal states
+const (
+	journalStarted = "started"
+	journalDeleted = "deleted"
+	journalFailed  = "failed"
+)
+
+// A Journal is an append-only log (JSON lines) of the progress of
+// resource-deletions, so an interrupted run can be resumed.
+type Journal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+
+	// last recorded state of each resource in a resumed journal
+	states map[string]string
+
+	// problems with a resumed journal which didn't stop us
+	warnings []string
+}
+
+type journalEntry struct {
+	Time     time.Time `json:"time"`
+	Resource string    `json:"resource"`
+	State    string    `json:"state"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// OpenJournal opens a journal for appending. If 'resume' is set we
+// also read in existing entries, and the plan will skip resources the
+// journal has recorded as deleted.
+func OpenJournal(path string, resume bool) (*Journal, error) {
+	j := &Journal{
+		states: map[string]string{},
+	}
+
+	if resume {
+		err := j.read(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %s", err)
+	}
+
+	// make sure we start on a fresh line, in case an earlier
+	// run was killed mid-write.
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("opening journal: %s", err)
+	}
+	if st.Size() > 0 {
+		b := make([]byte, 1)
+		_, err := f.ReadAt(b, st.Size()-1)
+		if err == nil && b[0] != '\n' {
+			_, err = f.Write([]byte{'\n'})
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("opening journal: %s", err)
+		}
+	}
+	j.f = f
+	j.enc = json.NewEncoder(f)
+
+	return j, nil
+}
+
+func (j *Journal) read(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// nothing to resume
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening journal: %s", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e journalEntry
+		err := json.Unmarshal(sc.Bytes(), &e)
+		if err != nil {
+			// a run which died mid-write may leave a partial
+			// line. At worst we delete something again.
+			j.warnings = append(j.warnings, fmt.Sprintf("ignoring journal line %d: %s", line, err))
+			continue
+		}
+		j.states[e.Resource] = e.State
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading journal: %s", err)
+	}
+
+	return nil
+}
+
+// Warnings returns the problems found reading a resumed journal which
+// didn't stop us from using it, such as a partly-written last line.
+func (j *Journal) Warnings() []string {
+	return j.warnings
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
+
+// deleted reports if a resumed journal recorded the resource as deleted.
+func (j *Journal) deleted(key string) bool {
+	if j == nil {
+		return false
+	}
+	return j.states[key] == journalDeleted
+}
+
+// started reports if a resumed journal recorded the resource's deletion
+// as started, but not as finished.
+func (j *Journal) started(key string) bool {
+	if j == nil {
+		return false
+	}
+	return j.states[key] == journalStarted
+}
+
+// record appends an entry to the journal.
+func (j *Journal) record(key string, state string, err error) error {
+	if j == nil {
+		return nil
+	}
+
+	e := journalEntry{
+		Time:     time.Now().UTC(),
+		Resource: key,
+		State:    state,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// each entry is a single write, so we don't lose earlier
+	// entries if we are killed.
+	if encErr := j.enc.Encode(e); encErr != nil {
+		return fmt.Errorf("writing journal: %s", encErr)
+	}
+	return nil
+}
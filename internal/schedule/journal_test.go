@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+// resumeObserver records resumed deletions.
+type resumeObserver struct {
+	NopObserver
+	mu      sync.Mutex
+	resumed []string
+}
+
+func (o *resumeObserver) DeletionResumed(r resource.Resource) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.resumed = append(o.resumed, r.String())
+}
+
+func TestJournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	err := os.WriteFile(path, []byte(`{"resource":"Subnet/s1","state":"deleted"}
+{"resource":"Cluster/c1","state":"started"}
+{"resource":"VPC/v1","sta`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := OpenJournal(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if len(j.Warnings()) != 1 {
+		t.Errorf("expected a warning about the partial line, got %q", j.Warnings())
+	}
+
+	cluster := &testProvider{typ: "Cluster", roots: []resource.Resource{res("Cluster", "c1")}}
+	vpc := &testProvider{
+		typ:        "VPC",
+		deps:       []string{"Cluster"},
+		roots:      []resource.Resource{res("VPC", "v1")},
+		dependents: map[string][]resource.Resource{"v1": {res("Subnet", "s1")}},
+	}
+	subnet := &testProvider{typ: "Subnet"}
+
+	var rec recorder
+	var obs resumeObserver
+	p := newTestPlan(&rec, cluster, vpc, subnet)
+	p.Journal = j
+	p.Observer = &obs
+	err = p.Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(rec.deleted, []string{"Cluster/c1", "VPC/v1"}) {
+		t.Errorf("expected Cluster/c1 and VPC/v1 to be deleted, got %v", rec.deleted)
+	}
+	if !slices.Equal(obs.resumed, []string{"Cluster/c1"}) {
+		t.Errorf("expected Cluster/c1 to be resumed, got %v", obs.resumed)
+	}
+}
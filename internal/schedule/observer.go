@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"log"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+// An Observer is notified as a plan makes progress. Methods may be called
+// concurrently, and should return quickly.
+type Observer interface {
+	// DiscoveryStarted is called before looking for root resources
+	// of a provider.
+	DiscoveryStarted(typ string)
+	// DiscoveryFinished is called after looking for root resources of a
+	// provider, with the number of resources found.
+	DiscoveryFinished(typ string, found int, err error)
+
+	// ResourceQueued is called when all dependencies of a resource
+	// are gone, and it is waiting for a free worker.
+	ResourceQueued(r resource.Resource)
+	// DeletionStarted is called when a worker starts deleting a resource.
+	// 'waited' is how long the resource was queued.
+	DeletionStarted(r resource.Resource, waited time.Duration)
+	// DeletionResumed is called after DeletionStarted when the journal
+	// shows that a previous run started deleting the resource, but
+	// didn't record how it went.
+	DeletionResumed(r resource.Resource)
+	// DeletionSucceeded is called when a resource has been deleted.
+	DeletionSucceeded(r resource.Resource, took time.Duration)
+	// DeletionFailed is called when a resource could not be deleted
+	// (after any retries).
+	DeletionFailed(r resource.Resource, err error)
+	// DeletionRetried is called before retrying a failed deletion.
+	DeletionRetried(r resource.Resource, attempt int, delay time.Duration, err error)
+	// DeletionSkipped is called for resources the plan won't delete.
+	DeletionSkipped(r resource.Resource, reason string)
+
+	// ProviderCompleted is called once every resource of a provider
+	// has been deleted, has failed, or has been skipped.
+	ProviderCompleted(typ string)
+}
+
+// NopObserver ignores all events. It may be embedded in types which only
+// care about some events.
+type NopObserver struct{}
+
+func (NopObserver) DiscoveryStarted(typ string)                               {}
+func (NopObserver) DiscoveryFinished(typ string, found int, err error)        {}
+func (NopObserver) ResourceQueued(r resource.Resource)                        {}
+func (NopObserver) DeletionStarted(r resource.Resource, waited time.Duration) {}
+func (NopObserver) DeletionResumed(r resource.Resource)                       {}
+func (NopObserver) DeletionSucceeded(r resource.Resource, took time.Duration) {}
+func (NopObserver) DeletionFailed(r resource.Resource, err error)             {}
+func (NopObserver) DeletionRetried(r resource.Resource, attempt int, delay time.Duration, err error) {
+}
+func (NopObserver) DeletionSkipped(r resource.Resource, reason string) {}
+func (NopObserver) ProviderCompleted(typ string)                       {}
+
+// LogObserver logs events with the standard logger. It is used by plans
+// which don't have an observer. Prefix is prepended to every message (to
+// tell apart plans running side by side, for example).
+type LogObserver struct {
+	Prefix string
+}
+
+func (LogObserver) DiscoveryStarted(typ string) {}
+
+func (o LogObserver) DiscoveryFinished(typ string, found int, err error) {
+	// errors are returned from the plan, so we don't log them
+	if err == nil && found > 0 {
+		log.Printf("%sfound %d %s", o.Prefix, found, typ)
+	}
+}
+
+func (LogObserver) ResourceQueued(r resource.Resource) {}
+
+func (o LogObserver) DeletionStarted(r resource.Resource, waited time.Duration) {
+	log.Printf("%sdeleting %s ...", o.Prefix, r)
+}
+
+func (o LogObserver) DeletionResumed(r resource.Resource) {
+	log.Printf("%sre-verifying %s: deletion was started by a previous run", o.Prefix, r)
+}
+
+func (LogObserver) DeletionSucceeded(r resource.Resource, took time.Duration) {}
+
+func (o LogObserver) DeletionFailed(r resource.Resource, err error) {
+	log.Printf("%serror: %s", o.Prefix, err)
+}
+
+func (o LogObserver) DeletionRetried(r resource.Resource, attempt int, delay time.Duration, err error) {
+	log.Printf("%sretrying %s in %s (attempt %d): %s", o.Prefix, r, delay.Round(time.Millisecond), attempt, err)
+}
+
+func (o LogObserver) DeletionSkipped(r resource.Resource, reason string) {
+	log.Printf("%sskipped %s: %s", o.Prefix, r, reason)
+}
+
+func (LogObserver) ProviderCompleted(typ string) {}
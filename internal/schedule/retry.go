@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+// A RetryPolicy describes how resource-deletions which fail on transient
+// dependency-errors are retried.
+type RetryPolicy struct {
+	// Timeout is how long we keep retrying a single resource, measured from
+	// the first attempt.
+	Timeout time.Duration
+	// InitialDelay is the delay before the first retry. Later delays double
+	// until they reach MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy we use when nothing else
+// is specified.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		Timeout:      10 * time.Minute,
+		InitialDelay: 2 * time.Second,
+		MaxDelay:     time.Minute,
+	}
+}
+
+// IsRetryable reports whether a failed deletion should be retried.
+func IsRetryable(err error) bool {
+	return resource.IsErrDependency(err)
+}
+
+// do calls fn until it succeeds, returns a non-retryable error, or
+// we run out of time. 'onRetry' is called before every retry. A nil
+// policy calls fn exactly once.
+func (rp *RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	if rp == nil {
+		return fn(ctx)
+	}
+
+	deadline := time.Now().Add(rp.Timeout)
+	delay := rp.InitialDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+
+		// jitter the delay so resources which failed together
+		// don't all retry together.
+		wait := delay/2 + rand.N(delay/2+1)
+		if time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		onRetry(attempt, wait, err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+
+		delay *= 2
+		if rp.MaxDelay > 0 {
+			delay = min(delay, rp.MaxDelay)
+		}
+	}
+}
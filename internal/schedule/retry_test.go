@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	inUse := &smithy.GenericAPIError{Code: "DependencyViolation"}
+	denied := &smithy.GenericAPIError{Code: "AccessDenied"}
+
+	tests := []struct {
+		name   string
+		policy *RetryPolicy
+		// errors returned by successive attempts, then nil
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{"success", DefaultRetryPolicy(), nil, nil, 1},
+		{"retried", DefaultRetryPolicy(), []error{inUse, inUse}, nil, 3},
+		{"not retryable", DefaultRetryPolicy(), []error{denied, inUse}, denied, 1},
+		{"retryable, then not", DefaultRetryPolicy(), []error{inUse, denied}, denied, 2},
+		{"no policy", nil, []error{inUse}, inUse, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.policy != nil {
+				tt.policy.InitialDelay = time.Millisecond
+			}
+			attempts := 0
+			err := tt.policy.do(context.Background(), func(ctx context.Context) error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			}, func(attempt int, delay time.Duration, err error) {})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDeadline(t *testing.T) {
+	rp := &RetryPolicy{
+		Timeout:      200 * time.Millisecond,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     40 * time.Millisecond,
+	}
+	inUse := &smithy.GenericAPIError{Code: "DependencyViolation"}
+
+	attempts := 0
+	var delays []time.Duration
+	start := time.Now()
+	err := rp.do(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return inUse
+	}, func(attempt int, delay time.Duration, err error) {
+		delays = append(delays, delay)
+	})
+	took := time.Since(start)
+
+	if !errors.Is(err, inUse) {
+		t.Errorf("expected the last error, got %v", err)
+	}
+	if attempts < 3 {
+		t.Errorf("expected several attempts, got %d", attempts)
+	}
+	// with some slack for the attempts themselves
+	if took > rp.Timeout+50*time.Millisecond {
+		t.Errorf("expected to give up within %s, took %s", rp.Timeout, took)
+	}
+	for _, d := range delays {
+		if d > rp.MaxDelay {
+			t.Errorf("delay %s is longer than the maximum %s", d, rp.MaxDelay)
+		}
+	}
+
+	// cancelling stops the retries
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	err = DefaultRetryPolicy().do(ctx, func(ctx context.Context) error {
+		attempts++
+		return inUse
+	}, func(attempt int, delay time.Duration, err error) {
+		cancel()
+	})
+	if !errors.Is(err, inUse) || attempts != 1 {
+		t.Errorf("expected one attempt after cancelling, got %d (error %v)", attempts, err)
+	}
+}
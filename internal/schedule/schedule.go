@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/aslatter/aws-project-scrub/internal/resource"
 
@@ -15,45 +16,199 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-// A Plan schedules the execution of resource-deletion actions. Resource-providers
-// are processed in dependency-order while deleting resources in parallel.
+// A Plan schedules the execution of resource-deletion actions. Each resource
+// is deleted as soon as the resources it depends on are gone, and resources
+// are deleted in parallel.
 type Plan struct {
 	Providers []resource.ResourceProvider
 	Settings  *resource.Settings
 	Filter    func(r resource.Resource) bool
 	Action    func(ctx context.Context, p resource.ResourceProvider, r resource.Resource) error
 
+	// Roots, if set, returns the root resources instead of asking the
+	// providers for them. Filter is not applied to these.
+	Roots func(ctx context.Context) ([]resource.Resource, error)
+
+	// IncludeType selects which providers take part in the plan. Excluded
+	// providers still order the deletions of other providers, but we
+	// don't look for their resources, and any found as dependents of
+	// other resources are not deleted (and neither is anything which
+	// needs them gone first). If nil, every provider is included.
+	IncludeType func(typ string) bool
+
+	// Retain is called for every resource in the plan, and returns a
+	// reason if the resource must not be deleted. Retained resources
+	// block the deletion of anything which needs them gone first. May
+	// be nil.
+	Retain func(r resource.Resource) string
+
+	// Retry controls retrying actions which fail because a resource
+	// is still in use. If nil, failed actions are not retried.
+	Retry *RetryPolicy
+
+	// Concurrency limits how many resources are deleted at the same
+	// time. If zero a default is used.
+	Concurrency int
+
+	// TypeConcurrency limits how many resources of a given type are
+	// deleted at the same time. These override limits from providers
+	// which implement resource.HasMaxConcurrency (and take the type out
+	// of its resource.HasConcurrencyGroup).
+	TypeConcurrency map[string]int
+
+	// DiscoveryConcurrency limits how many resource-lookups run
+	// concurrently. If zero a default is used.
+	DiscoveryConcurrency int
+
+	// Journal records the progress of deletions. When resuming from
+	// an existing journal, resources it has recorded as deleted are
+	// not deleted again. May be nil.
+	Journal *Journal
+
+	// Observer is notified of progress. If nil, a LogObserver
+	// is used.
+	Observer Observer
+
+	// KeepGoing keeps working the plan after a failed action. Resources
+	// which depend on the failed resource are skipped, and all failures
+	// are reported once everything else is done.
+	KeepGoing bool
+
 	// hook that any child goroutine can use to wind things down
 	abort func(error)
 
 	providers map[string]resource.ResourceProvider
+	observer  Observer
+
+	// dependencies between provider-types, and the types which
+	// come after each type (directly or not)
+	typeDeps        *dag.DAG
+	typeDescendants map[string]map[string]any
+
+	// dependencies between individual resources, keyed by the
+	// string-form of the resource. This only catches cycles: exec
+	// works from 'dependsOn' and the provider-level dependencies.
 	deps      *dag.DAG
-	resources map[string]map[string]resource.Resource
+	resources map[string]resource.Resource
+
+	// the dependencies between resources reported by providers (as
+	// opposed to those implied by provider-types)
+	dependsOn map[string]map[string]bool
+
+	// resources we won't delete, and why
+	retained map[string]string
 
-	doneSignal       chan string
+	// guards 'resources', 'dependsOn' and 'retained' during discovery
+	resourcesMu sync.Mutex
+
+	doneSignal       chan resourceResult
 	availableWorkers *semaphore.Weighted
+
+	// per-type limits on workers, for types which have them
+	typeWorkers map[string]*semaphore.Weighted
 }
 
+const defaultConcurrency = 20
+
+// Exec discovers resources and then deletes them.
 func (p *Plan) Exec(ctx context.Context) error {
-	return (&Plan{
+	q := p.clone()
+	err := q.init()
+	if err != nil {
+		return err
+	}
+
+	// find root resources and dependent resources.
+	// (discovering dependent-resources adds edges to our DAG)
+	err = q.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	return q.exec(ctx)
+}
+
+// Discover discovers resources without deleting anything. The returned
+// snapshot may be passed to Apply later on.
+func (p *Plan) Discover(ctx context.Context) (*Snapshot, error) {
+	q := p.clone()
+	err := q.init()
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.snapshot()
+}
+
+// Apply deletes exactly the resources in the passed-in snapshot, in the
+// order recorded in the snapshot. The snapshot must have been taken in the
+// same account and region as our settings.
+func (p *Plan) Apply(ctx context.Context, snap *Snapshot) error {
+	q := p.clone()
+	err := q.init()
+	if err != nil {
+		return err
+	}
+
+	err = q.load(snap)
+	if err != nil {
+		return err
+	}
+
+	return q.exec(ctx)
+}
+
+// clone copies the exported fields of a plan, so we get fresh
+// internal state for every run.
+func (p *Plan) clone() *Plan {
+	return &Plan{
 		Providers: p.Providers,
 		Settings:  p.Settings,
 		Filter:    p.Filter,
 		Action:    p.Action,
-	}).exec(ctx)
+		Roots:     p.Roots,
+
+		IncludeType: p.IncludeType,
+		Retain:      p.Retain,
+
+		Retry:     p.Retry,
+		Journal:   p.Journal,
+		Observer:  p.Observer,
+		KeepGoing: p.KeepGoing,
+
+		Concurrency:          p.Concurrency,
+		TypeConcurrency:      p.TypeConcurrency,
+		DiscoveryConcurrency: p.DiscoveryConcurrency,
+	}
 }
 
-func (p *Plan) exec(ctx context.Context) error {
+// init sets up the dependency graphs and the relationships
+// between providers.
+func (p *Plan) init() error {
 
 	// we don't use much from this DAG library, but it does tell
 	// us up-front if we have dependency cycles.
+	p.typeDeps = dag.NewDAG()
 	p.deps = dag.NewDAG()
+	p.resources = map[string]resource.Resource{}
+	p.dependsOn = map[string]map[string]bool{}
+	p.retained = map[string]string{}
+
+	p.observer = p.Observer
+	if p.observer == nil {
+		p.observer = LogObserver{}
+	}
 
 	// build up providers and relationships between providers
 	p.providers = map[string]resource.ResourceProvider{}
 	for _, pr := range p.Providers {
 		p.providers[pr.Type()] = pr
-		err := p.deps.AddVertexByID(pr.Type(), pr)
+		err := p.typeDeps.AddVertexByID(pr.Type(), pr)
 		if err != nil {
 			return fmt.Errorf("adding provider to dependency graph %q: %s", pr.Type(), err)
 		}
@@ -65,188 +220,421 @@ func (p *Plan) exec(ctx context.Context) error {
 			continue
 		}
 		for _, dep := range hasDeps.Dependencies() {
-			err := p.deps.AddEdge(dep, pr.Type())
+			if _, ok := p.providers[dep]; !ok {
+				// not in this plan (regional types, in a plan
+				// of only global ones), so nothing to wait for
+				continue
+			}
+			err := p.typeDeps.AddEdge(dep, pr.Type())
 			if err != nil && !isDuplicateEdgeError(err) {
 				return fmt.Errorf("adding dependency on %q from %q: %s", dep, pr.Type(), err)
 			}
 		}
 	}
 
-	// find root resources and dependent resources.
-	// (discovering dependent-resources adds edges to our DAG)
-	p.resources = map[string]map[string]resource.Resource{}
-	for _, pr := range p.Providers {
-		finder, ok := pr.(resource.HasRootResources)
-		if !ok {
-			continue
-		}
-		rs, err := finder.FindResources(ctx, p.Settings)
+	p.typeDescendants = map[string]map[string]any{}
+	for typ := range p.providers {
+		var err error
+		p.typeDescendants[typ], err = p.typeDeps.GetDescendants(typ)
 		if err != nil {
-			return fmt.Errorf("finding root resources for %q: %s", pr.Type(), err)
-		}
-		for _, r := range rs {
-			if !p.Filter(r) {
-				continue
-			}
-			err := p.addOneResource(ctx, r)
-			if err != nil {
-				return fmt.Errorf("adding resource %q: %s", r, err)
-			}
+			return fmt.Errorf("getting descendants of %q: %s", typ, err)
 		}
 	}
 
+	return nil
+}
+
+// exec deletes the resources in the plan.
+func (p *Plan) exec(ctx context.Context) error {
 	//
 	// prep data-structures for working the plan
 	//
 
-	// providers which have completed
-	doneProviders := map[string]bool{}
+	// resources which have been deleted
+	doneResources := map[string]bool{}
+
+	// resources which have been deleted, have failed, or
+	// have been skipped
+	finishedResources := map[string]bool{}
+
+	// resources which have not been started
+	pendingResources := map[string]bool{}
+
+	// failed actions (only more than one with 'KeepGoing')
+	var failures []error
+
+	// count of unfinished resources for each provider
+	unfinishedByType := map[string]int{}
+	for _, r := range p.resources {
+		unfinishedByType[r.Type]++
+	}
+	finish := func(k string) {
+		finishedResources[k] = true
+		typ := p.resources[k].Type
+		unfinishedByType[typ]--
+		if unfinishedByType[typ] == 0 {
+			p.observer.ProviderCompleted(typ)
+		}
+	}
+
+	// Provider-level dependencies apply to every resource of the
+	// dependent provider: a resource waits until every resource of its
+	// provider's ancestors is gone. Rather than adding an edge between
+	// each pair of resources we count the resources of each type which
+	// are yet to be deleted. Retained resources aren't counted: they
+	// aren't going anywhere, and only block the resources which reported
+	// them as dependents.
+	undeletedByType := map[string]int{}
+	keysByType := map[string][]string{}
+	for k, r := range p.resources {
+		pendingResources[k] = true
+		keysByType[r.Type] = append(keysByType[r.Type], k)
+		if _, ok := p.retained[k]; !ok {
+			undeletedByType[r.Type]++
+		}
+	}
+	typeAncestors := map[string]map[string]any{}
+	for typ := range p.providers {
+		var err error
+		typeAncestors[typ], err = p.typeDeps.GetAncestors(typ)
+		if err != nil {
+			return fmt.Errorf("getting ancestors of %q: %s", typ, err)
+		}
+	}
+
+	// resources which reported each resource as a dependent
+	dependents := map[string][]string{}
+	for k, deps := range p.dependsOn {
+		for dep := range deps {
+			dependents[dep] = append(dependents[dep], k)
+		}
+	}
+
+	// ready reports if everything a pending resource waits on is gone.
+	ready := func(k string) bool {
+		for dep := range p.dependsOn[k] {
+			if !doneResources[dep] {
+				return false
+			}
+		}
+		for typ := range typeAncestors[p.resources[k].Type] {
+			if undeletedByType[typ] > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	// deleted records that a resource is gone, and returns the pending
+	// resources which may be ready because of it.
+	deleted := func(k string) []string {
+		doneResources[k] = true
+		next := dependents[k]
+		if _, ok := p.retained[k]; ok {
+			return next
+		}
+		typ := p.resources[k].Type
+		undeletedByType[typ]--
+		if undeletedByType[typ] == 0 {
+			next = slices.Clone(next)
+			for d := range p.typeDescendants[typ] {
+				next = append(next, keysByType[d]...)
+			}
+		}
+		return next
+	}
 
-	// providers which have not completed
-	pendingProviders := map[string]bool{}
+	// skip skips the pending resources which need a resource gone first
+	// (because it is retained, or failed), and so on down the line.
+	blockedTypes := map[string]bool{}
+	skip := func(key string, reason string) {
+		queue := []string{key}
+		skipOne := func(k string) {
+			if !pendingResources[k] {
+				return
+			}
+			delete(pendingResources, k)
+			finish(k)
+			p.observer.DeletionSkipped(p.resources[k], reason)
+			queue = append(queue, k)
+		}
+		for len(queue) > 0 {
+			k := queue[0]
+			queue = queue[1:]
+			for _, d := range dependents[k] {
+				skipOne(d)
+			}
+			if _, ok := p.retained[k]; ok {
+				continue
+			}
+			typ := p.resources[k].Type
+			if blockedTypes[typ] {
+				continue
+			}
+			blockedTypes[typ] = true
+			for d := range p.typeDescendants[typ] {
+				for _, dk := range keysByType[d] {
+					skipOne(dk)
+				}
+			}
+		}
+	}
 
-	// signal for done providers
-	p.doneSignal = make(chan string, len(p.providers))
+	// signal for done resources
+	p.doneSignal = make(chan resourceResult, len(p.resources))
 
 	ctx, ctxDone := context.WithCancelCause(ctx)
 	p.abort = ctxDone
 	defer ctxDone(nil)
 
-	// allow deleting up to 20 resources concurrently. We
-	// may have less concurrency than this if dependencies
-	// are not met.
-	p.availableWorkers = semaphore.NewWeighted(20)
+	// allow deleting up to 20 resources concurrently (by default). We
+	// may have less concurrency than this if dependencies are not met,
+	// or if a provider has its own limit.
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	p.availableWorkers = semaphore.NewWeighted(int64(concurrency))
+
+	// providers in a concurrency-group share their workers
+	p.typeWorkers = map[string]*semaphore.Weighted{}
+	groupWorkers := map[string]*semaphore.Weighted{}
+	for typ, pr := range p.providers {
+		if n, ok := p.TypeConcurrency[typ]; ok {
+			if n > 0 {
+				p.typeWorkers[typ] = semaphore.NewWeighted(int64(n))
+			}
+			continue
+		}
+		hasMax, ok := pr.(resource.HasMaxConcurrency)
+		if !ok || hasMax.MaxConcurrency() <= 0 {
+			continue
+		}
+		group := typ
+		if hasGroup, ok := pr.(resource.HasConcurrencyGroup); ok {
+			group = hasGroup.ConcurrencyGroup()
+		}
+		if groupWorkers[group] == nil {
+			groupWorkers[group] = semaphore.NewWeighted(int64(hasMax.MaxConcurrency()))
+		}
+		p.typeWorkers[typ] = groupWorkers[group]
+	}
 
 	//
 	// start execution
 	//
 
-	// move all providers to pending
-	for k := range p.providers {
-		pendingProviders[k] = true
+	// providers with nothing to do are already done
+	for typ := range p.providers {
+		if unfinishedByType[typ] == 0 {
+			p.observer.ProviderCompleted(typ)
+		}
+	}
+
+	// retained resources are never deleted, and neither is anything
+	// which needs them gone first.
+	for k, reason := range p.retained {
+		delete(pendingResources, k)
+		finish(k)
+		p.observer.DeletionSkipped(p.resources[k], reason)
+	}
+	for k, reason := range p.retained {
+		skip(k, fmt.Sprintf("%s would block deletion (%s)", k, reason))
+	}
+
+	// resources which a previous run has already deleted
+	for k, r := range p.resources {
+		if !pendingResources[k] || !p.Journal.deleted(k) {
+			continue
+		}
+		delete(pendingResources, k)
+		deleted(k)
+		finish(k)
+		p.observer.DeletionSkipped(r, "already deleted")
 	}
 
-	// start all providers without dependencies
-	for k := range p.deps.GetRoots() {
-		delete(pendingProviders, k)
-		go p.processOneProvider(ctx, k)
+	// resources being deleted
+	inFlight := 0
+	start := func(k string) {
+		delete(pendingResources, k)
+		inFlight++
+		go p.processOneResource(ctx, k)
+	}
+
+	// start all resources without pending dependencies
+	for k := range pendingResources {
+		if ready(k) {
+			start(k)
+		}
 	}
 
-	// queue up providers as they become ready
-	for len(doneProviders) < len(p.Providers) {
+	// queue up resources as they become ready
+	for len(finishedResources) < len(p.resources) {
+		if inFlight == 0 {
+			// nothing will finish, so the rest wait on each other
+			// through a mix of resource and provider dependencies
+			return errors.Join(append(failures, stuckError(pendingResources))...)
+		}
+
 		select {
 		case <-ctx.Done():
-			// TODO - capture multiple errors?
-			return context.Cause(ctx)
-
-		// a provider finished! Do stuff.
-		case doneTyp := <-p.doneSignal:
-			doneProviders[doneTyp] = true
-
-		evalLoop:
-			for _, evalType := range slices.Collect(maps.Keys(pendingProviders)) {
-				ancestors, err := p.deps.GetAncestors(evalType)
-				if err != nil {
-					// ?!
-					return fmt.Errorf("getting ancestors of %q: %s", evalType, err)
-				}
-				for ancestorTyp := range ancestors {
-					if !doneProviders[ancestorTyp] {
-						continue evalLoop
-					}
+			return errors.Join(append(failures, context.Cause(ctx))...)
+
+		case result := <-p.doneSignal:
+			inFlight--
+			finish(result.key)
+
+			if result.err != nil {
+				if !p.KeepGoing {
+					return result.err
 				}
-				// all dependencies are met!
-				delete(pendingProviders, evalType)
-				go p.processOneProvider(ctx, evalType)
+				failures = append(failures, result.err)
+
+				// anything waiting on the failed resource can't
+				// be deleted.
+				skip(result.key, "blocked by "+result.key)
+				continue
 			}
 
+			// a resource is gone! Start anything which was
+			// only waiting on it.
+			for _, k := range deleted(result.key) {
+				if pendingResources[k] && ready(k) {
+					start(k)
+				}
+			}
 		}
 	}
 
-	return nil
+	return errors.Join(failures...)
 }
 
-// addOneResource adds a resource to the plan. If the resource has
-// dynamically-discovered dependencies, those are recursively added
-// as well.
-func (p *Plan) addOneResource(ctx context.Context, r resource.Resource) error {
-	pr, ok := p.providers[r.Type]
-	if !ok {
-		return fmt.Errorf("unknown provider-id for resource %q: %s", r, r.Type)
+// stuckError describes resources which can't be deleted because they
+// wait on each other.
+func stuckError(pending map[string]bool) error {
+	const most = 5
+	keys := slices.Sorted(maps.Keys(pending))
+	list := strings.Join(keys[:min(len(keys), most)], ", ")
+	if len(keys) > most {
+		list += fmt.Sprintf(" and %d more", len(keys)-most)
 	}
+	return fmt.Errorf("dependency cycle: %s can't be deleted, as they wait on each other", list)
+}
 
-	typMap, ok := p.resources[r.Type]
-	if !ok {
-		typMap = map[string]resource.Resource{}
-		p.resources[r.Type] = typMap
-	}
-	idStr := strings.Join(r.ID, "/")
-	if _, ok := typMap[idStr]; ok {
-		// done!
-		return nil
+// addDependency records that 'dep' must be deleted before 'r'. It is safe
+// to call concurrently.
+func (p *Plan) addDependency(dep, r string) error {
+	// resources of types which come after r's type wait for r, so
+	// they can't go first
+	p.resourcesMu.Lock()
+	depType, rType := p.resources[dep].Type, p.resources[r].Type
+	p.resourcesMu.Unlock()
+	if _, ok := p.typeDescendants[rType][depType]; ok {
+		return fmt.Errorf("dependency cycle: %s resources are deleted after %s resources", depType, rType)
 	}
-	typMap[idStr] = r
 
-	// find more resources
-	depProvider, ok := pr.(resource.HasDependentResources)
-	if !ok {
-		return nil
-	}
-	moreResources, err := depProvider.DependentResources(ctx, p.Settings, r)
-	if err != nil {
-		return fmt.Errorf("looking up dependent resources for %q: %s", r, err)
+	err := p.deps.AddEdge(dep, r)
+	if err != nil && !isDuplicateEdgeError(err) {
+		return err
 	}
-	for _, nextResource := range moreResources {
-		err := p.addOneResource(ctx, nextResource)
-		if err != nil {
-			return fmt.Errorf("adding dependent resource %q: %s", nextResource, err)
-		}
 
-		err = p.deps.AddEdge(nextResource.Type, r.Type)
-		if err != nil && !isDuplicateEdgeError(err) {
-			return fmt.Errorf("adding dependency on %q from %q: %s", nextResource.Type, r.Type, err)
-		}
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+	if p.dependsOn[r] == nil {
+		p.dependsOn[r] = map[string]bool{}
 	}
-
+	p.dependsOn[r][dep] = true
 	return nil
 }
 
+// includesType reports if resources of a provider-type may be deleted.
+func (p *Plan) includesType(typ string) bool {
+	return p.IncludeType == nil || p.IncludeType(typ)
+}
+
+// isRetained reports if a resource won't be deleted. It is safe to call
+// concurrently.
+func (p *Plan) isRetained(key string) bool {
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+	_, ok := p.retained[key]
+	return ok
+}
+
 func isDuplicateEdgeError(err error) bool {
 	var isEdgeErr dag.EdgeDuplicateError
 	return errors.As(err, &isEdgeErr)
 }
 
-// processOneProvider deletes all resources associated with a single
-// resource-provider. Once complete it will send it's provider-type
-// down the 'doneSignal' channel. Resources will be processed in
-// parallel.
-func (p *Plan) processOneProvider(ctx context.Context, typ string) {
-	defer func() {
-		p.doneSignal <- typ
-	}()
+type resourceResult struct {
+	key string
+	err error
+}
 
-	pr, ok := p.providers[typ]
+// processOneResource deletes a single resource. Once complete it will
+// send the resource's key (and any error) down the 'doneSignal' channel.
+func (p *Plan) processOneResource(ctx context.Context, key string) {
+	r := p.resources[key]
+	pr, ok := p.providers[r.Type]
 	if !ok {
-		p.abort(fmt.Errorf("processProvider: unknown type %q", typ))
+		p.abort(fmt.Errorf("processResource: unknown type %q", r.Type))
+		return
 	}
 
-	var wg sync.WaitGroup
-	for r := range maps.Values(p.resources[typ]) {
-		err := p.availableWorkers.Acquire(ctx, 1)
+	p.observer.ResourceQueued(r)
+	queued := time.Now()
+
+	// take a slot for our type before taking a general worker,
+	// so we don't hold up other types while we wait.
+	if typeWorkers, ok := p.typeWorkers[r.Type]; ok {
+		err := typeWorkers.Acquire(ctx, 1)
 		if err != nil {
 			// context canceled
 			return
 		}
-		wg.Add(1)
-		go func() {
-			defer p.availableWorkers.Release(1)
-			defer wg.Done()
+		defer typeWorkers.Release(1)
+	}
 
-			err := p.Action(ctx, pr, r)
-			if err != nil {
-				p.abort(err)
-			}
-		}()
+	err := p.availableWorkers.Acquire(ctx, 1)
+	if err != nil {
+		// context canceled
+		return
+	}
+	defer p.availableWorkers.Release(1)
+
+	started := time.Now()
+	p.observer.DeletionStarted(r, started.Sub(queued))
+
+	if p.Journal.started(key) {
+		p.observer.DeletionResumed(r)
 	}
-	wg.Wait()
+	err = p.Journal.record(key, journalStarted, nil)
+	if err != nil {
+		p.abort(err)
+		return
+	}
+
+	err = p.Retry.do(ctx, func(ctx context.Context) error {
+		return p.Action(ctx, pr, r)
+	}, func(attempt int, delay time.Duration, err error) {
+		p.observer.DeletionRetried(r, attempt, delay, err)
+	})
+	if err != nil {
+		err = fmt.Errorf("deleting %s: %w", r, err)
+		p.observer.DeletionFailed(r, err)
+		jerr := p.Journal.record(key, journalFailed, err)
+		if jerr != nil {
+			p.abort(jerr)
+			return
+		}
+	} else {
+		p.observer.DeletionSucceeded(r, time.Since(started))
+		jerr := p.Journal.record(key, journalDeleted, nil)
+		if jerr != nil {
+			p.abort(jerr)
+			return
+		}
+	}
+
+	p.doneSignal <- resourceResult{key: key, err: err}
 }
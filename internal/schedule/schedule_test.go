@@ -0,0 +1,363 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+// testProvider is a resource-provider with canned resources.
+type testProvider struct {
+	typ        string
+	deps       []string
+	roots      []resource.Resource
+	dependents map[string][]resource.Resource
+}
+
+func (t *testProvider) Type() string {
+	return t.typ
+}
+
+func (t *testProvider) DeleteResource(ctx context.Context, s *resource.Settings, r resource.Resource) error {
+	return nil
+}
+
+func (t *testProvider) Dependencies() []string {
+	return t.deps
+}
+
+func (t *testProvider) FindResources(ctx context.Context, s *resource.Settings) ([]resource.Resource, error) {
+	return t.roots, nil
+}
+
+func (t *testProvider) DependentResources(ctx context.Context, s *resource.Settings, r resource.Resource) ([]resource.Resource, error) {
+	return t.dependents[r.ID[0]], nil
+}
+
+func res(typ, id string) resource.Resource {
+	return resource.Resource{Type: typ, ID: []string{id}}
+}
+
+// recorder is a plan-action which records the order of deletions.
+type recorder struct {
+	mu      sync.Mutex
+	deleted []string
+	fail    map[string]bool
+}
+
+func (rec *recorder) action(ctx context.Context, p resource.ResourceProvider, r resource.Resource) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.fail[r.String()] {
+		return errors.New("failed")
+	}
+	rec.deleted = append(rec.deleted, r.String())
+	return nil
+}
+
+func (rec *recorder) before(t *testing.T, first, second string) {
+	t.Helper()
+	i := slices.Index(rec.deleted, first)
+	j := slices.Index(rec.deleted, second)
+	if i < 0 || j < 0 || i > j {
+		t.Errorf("expected %s to be deleted before %s, got %v", first, second, rec.deleted)
+	}
+}
+
+func newTestPlan(rec *recorder, providers ...resource.ResourceProvider) *Plan {
+	return &Plan{
+		Providers: providers,
+		Settings:  &resource.Settings{},
+		Filter:    func(r resource.Resource) bool { return true },
+		Action:    rec.action,
+		Observer:  NopObserver{},
+	}
+}
+
+func TestExecOrder(t *testing.T) {
+	// clusters go before VPCs (by provider), and subnets before
+	// their VPC (as dependents)
+	cluster := &testProvider{typ: "Cluster", roots: []resource.Resource{res("Cluster", "c1")}}
+	vpc := &testProvider{
+		typ:   "VPC",
+		deps:  []string{"Cluster"},
+		roots: []resource.Resource{res("VPC", "v1"), res("VPC", "v2")},
+		dependents: map[string][]resource.Resource{
+			"v1": {res("Subnet", "s1")},
+			"v2": {res("Subnet", "s2")},
+		},
+	}
+	subnet := &testProvider{typ: "Subnet"}
+
+	var rec recorder
+	err := newTestPlan(&rec, vpc, subnet, cluster).Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.deleted) != 5 {
+		t.Fatalf("expected 5 deletions, got %v", rec.deleted)
+	}
+	rec.before(t, "Cluster/c1", "VPC/v1")
+	rec.before(t, "Cluster/c1", "VPC/v2")
+	rec.before(t, "Subnet/s1", "VPC/v1")
+	rec.before(t, "Subnet/s2", "VPC/v2")
+}
+
+func TestExecKeepGoing(t *testing.T) {
+	// a failed cluster blocks every VPC, and with them their subnets'
+	// dependents; the unrelated log group still goes
+	cluster := &testProvider{typ: "Cluster", roots: []resource.Resource{res("Cluster", "c1")}}
+	vpc := &testProvider{typ: "VPC", deps: []string{"Cluster"}, roots: []resource.Resource{res("VPC", "v1")}}
+	role := &testProvider{typ: "Role", deps: []string{"VPC"}, roots: []resource.Resource{res("Role", "r1")}}
+	logs := &testProvider{typ: "LogGroup", roots: []resource.Resource{res("LogGroup", "l1")}}
+
+	rec := recorder{fail: map[string]bool{"Cluster/c1": true}}
+	p := newTestPlan(&rec, cluster, vpc, role, logs)
+	p.KeepGoing = true
+	err := p.Exec(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !slices.Equal(rec.deleted, []string{"LogGroup/l1"}) {
+		t.Errorf("expected only LogGroup/l1 to be deleted, got %v", rec.deleted)
+	}
+}
+
+func TestExecRetained(t *testing.T) {
+	// a retained subnet blocks its VPC, but not the other VPC
+	vpc := &testProvider{
+		typ:        "VPC",
+		roots:      []resource.Resource{res("VPC", "v1"), res("VPC", "v2")},
+		dependents: map[string][]resource.Resource{"v1": {res("Subnet", "s1")}},
+	}
+	subnet := &testProvider{typ: "Subnet"}
+
+	var rec recorder
+	p := newTestPlan(&rec, vpc, subnet)
+	p.Retain = func(r resource.Resource) string {
+		if r.String() == "Subnet/s1" {
+			return "protected"
+		}
+		return ""
+	}
+	err := p.Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rec.deleted, []string{"VPC/v2"}) {
+		t.Errorf("expected only VPC/v2 to be deleted, got %v", rec.deleted)
+	}
+}
+
+// taggedProvider is a test provider which looks up the tags of its
+// resources.
+type taggedProvider struct {
+	testProvider
+	tags map[string]map[string]string
+}
+
+func (t *taggedProvider) ResourceTags(ctx context.Context, s *resource.Settings, r resource.Resource) (map[string]string, error) {
+	return t.tags[r.ID[0]], nil
+}
+
+func TestExecProtectedDependent(t *testing.T) {
+	// a nodegroup is listed without its tags, which mark it as
+	// protected, so its cluster must not be deleted
+	cluster := &testProvider{
+		typ:        "Cluster",
+		roots:      []resource.Resource{res("Cluster", "c1"), res("Cluster", "c2")},
+		dependents: map[string][]resource.Resource{"c1": {res("Nodegroup", "n1")}, "c2": {res("Nodegroup", "n2")}},
+	}
+	nodegroup := &taggedProvider{
+		testProvider: testProvider{typ: "Nodegroup"},
+		tags: map[string]map[string]string{
+			"n1": {"protect": "true"},
+			"n2": {},
+		},
+	}
+
+	var rec recorder
+	p := newTestPlan(&rec, cluster, nodegroup)
+	p.Retain = func(r resource.Resource) string {
+		if r.Tags["protect"] == "true" {
+			return "protected"
+		}
+		return ""
+	}
+	err := p.Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(rec.deleted)
+	if !slices.Equal(rec.deleted, []string{"Cluster/c2", "Nodegroup/n2"}) {
+		t.Errorf("expected only Cluster/c2 and Nodegroup/n2 to be deleted, got %v", rec.deleted)
+	}
+}
+
+func TestDiscoverCycle(t *testing.T) {
+	// a VPC reports a subnet as a dependent, but subnets are
+	// deleted after VPCs
+	vpc := &testProvider{
+		typ:        "VPC",
+		roots:      []resource.Resource{res("VPC", "v1")},
+		dependents: map[string][]resource.Resource{"v1": {res("Subnet", "s1")}},
+	}
+	subnet := &testProvider{typ: "Subnet", deps: []string{"VPC"}}
+
+	var rec recorder
+	_, err := newTestPlan(&rec, vpc, subnet).Discover(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected a dependency cycle error, got %v", err)
+	}
+
+	// the same, from a hand-edited plan
+	snap := &Snapshot{
+		Version: snapshotVersion,
+		Resources: []SnapshotResource{
+			{Type: "VPC", ID: []string{"v1"}, DependsOn: []string{"Subnet/s1"}},
+			{Type: "Subnet", ID: []string{"s1"}},
+		},
+	}
+	err = newTestPlan(&rec, vpc, subnet).Apply(context.Background(), snap)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected a dependency cycle error, got %v", err)
+	}
+	if len(rec.deleted) != 0 {
+		t.Errorf("expected nothing to be deleted, got %v", rec.deleted)
+	}
+}
+
+func TestExecCycle(t *testing.T) {
+	// a cycle through two resource-dependencies and a provider
+	// dependency: v1 waits on s1, s1 on i1, and instances on VPCs
+	vpc := &testProvider{
+		typ:        "VPC",
+		roots:      []resource.Resource{res("VPC", "v1")},
+		dependents: map[string][]resource.Resource{"v1": {res("Subnet", "s1")}},
+	}
+	subnet := &testProvider{
+		typ:        "Subnet",
+		dependents: map[string][]resource.Resource{"s1": {res("Instance", "i1")}},
+	}
+	instance := &testProvider{typ: "Instance", deps: []string{"VPC"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var rec recorder
+	err := newTestPlan(&rec, vpc, subnet, instance).Exec(ctx)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected a dependency cycle error, got %v", err)
+	}
+	if len(rec.deleted) != 0 {
+		t.Errorf("expected nothing to be deleted, got %v", rec.deleted)
+	}
+}
+
+func TestApplyManyResources(t *testing.T) {
+	// a chain of providers with many resources each, which took
+	// minutes when provider-dependencies were expanded into
+	// resource-dependencies
+	const perType = 500
+	var providers []resource.ResourceProvider
+	var prev []string
+	for i := range 4 {
+		pr := &testProvider{typ: fmt.Sprintf("T%d", i), deps: prev}
+		for j := range perType {
+			pr.roots = append(pr.roots, res(pr.typ, fmt.Sprint(j)))
+		}
+		providers = append(providers, pr)
+		prev = []string{pr.typ}
+	}
+
+	var rec recorder
+	p := newTestPlan(&rec, providers...)
+	snap, err := p.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = p.Apply(context.Background(), snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("applying %d resources took %s", len(snap.Resources), took)
+	}
+	if len(rec.deleted) != 4*perType {
+		t.Fatalf("expected %d deletions, got %d", 4*perType, len(rec.deleted))
+	}
+	rec.before(t, "T0/499", "T1/0")
+	rec.before(t, "T2/499", "T3/0")
+}
+
+func TestExecMissingDependency(t *testing.T) {
+	// a plan of only global providers leaves out the regional types
+	// they depend on
+	role := &testProvider{typ: "Role", deps: []string{"Cluster"}, roots: []resource.Resource{res("Role", "r1")}}
+	policy := &testProvider{typ: "Policy", deps: []string{"Role", "Instance"}, roots: []resource.Resource{res("Policy", "p1")}}
+
+	var rec recorder
+	p := newTestPlan(&rec, policy, role)
+	snap, err := p.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Apply(context.Background(), snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.deleted) != 2 {
+		t.Fatalf("expected 2 deletions, got %v", rec.deleted)
+	}
+	rec.before(t, "Role/r1", "Policy/p1")
+}
+
+// groupedProvider shares a concurrency limit of one with other
+// grouped providers.
+type groupedProvider struct {
+	testProvider
+}
+
+func (*groupedProvider) MaxConcurrency() int {
+	return 1
+}
+
+func (*groupedProvider) ConcurrencyGroup() string {
+	return "group"
+}
+
+func TestExecConcurrencyGroup(t *testing.T) {
+	a := &groupedProvider{testProvider{typ: "A", roots: []resource.Resource{res("A", "1"), res("A", "2")}}}
+	b := &groupedProvider{testProvider{typ: "B", roots: []resource.Resource{res("B", "1"), res("B", "2")}}}
+
+	var mu sync.Mutex
+	running, most := 0, 0
+	p := newTestPlan(&recorder{}, a, b)
+	p.Action = func(ctx context.Context, pr resource.ResourceProvider, r resource.Resource) error {
+		mu.Lock()
+		running++
+		most = max(most, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+	err := p.Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if most != 1 {
+		t.Errorf("expected one deletion at a time across the group, got %d", most)
+	}
+}
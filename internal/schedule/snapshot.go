@@ -0,0 +1,197 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+const snapshotVersion = 1
+
+// A Snapshot is a serializable record of discovered resources, and the
+// dependencies between them. It records where the resources were
+// discovered so it can't be applied to a different account or region.
+type Snapshot struct {
+	Version   int                `json:"version"`
+	Account   string             `json:"account"`
+	Region    string             `json:"region"`
+	Partition string             `json:"partition"`
+	Providers []SnapshotProvider `json:"providers"`
+	Resources []SnapshotResource `json:"resources"`
+}
+
+// A SnapshotProvider is a resource-provider in a snapshot. DependsOn lists
+// the provider-types which must be processed first.
+type SnapshotProvider struct {
+	Type      string   `json:"type"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// A SnapshotResource is a resource in a snapshot. DependsOn lists the
+// resources (by their string-form) which must be deleted first, not counting
+// the ordering between provider-types. If Retained is set the resource
+// won't be deleted, for the given reason.
+type SnapshotResource struct {
+	Type      string            `json:"type"`
+	ID        []string          `json:"id"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+	Retained  string            `json:"retained,omitempty"`
+}
+
+// Resource returns the resource the snapshot-entry describes.
+func (r SnapshotResource) Resource() resource.Resource {
+	result := resource.Resource{
+		Type: r.Type,
+		ID:   r.ID,
+		Tags: r.Tags,
+	}
+	if r.CreatedAt != nil {
+		result.CreatedAt = *r.CreatedAt
+	}
+	return result
+}
+
+// Blocked returns the resources which can't be deleted because they need
+// a retained resource gone first, mapped to the retained resource which
+// blocks them. 'retained' reports if a resource is retained.
+func (s *Snapshot) Blocked(retained func(r SnapshotResource) bool) map[string]string {
+	// resources, by the resources they depend on
+	dependents := map[string][]string{}
+	isRetained := map[string]bool{}
+	for _, sr := range s.Resources {
+		k := sr.Resource().String()
+		for _, dep := range sr.DependsOn {
+			dependents[dep] = append(dependents[dep], k)
+		}
+		isRetained[k] = retained(sr)
+	}
+
+	result := map[string]string{}
+	for _, sr := range s.Resources {
+		blocker := sr.Resource().String()
+		if !isRetained[blocker] {
+			continue
+		}
+		queue := dependents[blocker]
+		for len(queue) > 0 {
+			k := queue[0]
+			queue = queue[1:]
+			if _, ok := result[k]; ok || isRetained[k] {
+				continue
+			}
+			result[k] = blocker
+			queue = append(queue, dependents[k]...)
+		}
+	}
+	return result
+}
+
+// ReadSnapshot reads a JSON snapshot.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snap Snapshot
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&snap)
+	if err != nil {
+		return nil, fmt.Errorf("decoding plan: %s", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d)", snap.Version, snapshotVersion)
+	}
+	return &snap, nil
+}
+
+// Write writes the snapshot as JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// snapshot records the resources and dependencies of the plan.
+func (p *Plan) snapshot() (*Snapshot, error) {
+	snap := &Snapshot{
+		Version:   snapshotVersion,
+		Account:   p.Settings.Account,
+		Region:    p.Settings.Region,
+		Partition: p.Settings.Partition,
+	}
+
+	for _, typ := range slices.Sorted(maps.Keys(p.providers)) {
+		parents, err := p.typeDeps.GetParents(typ)
+		if err != nil {
+			return nil, fmt.Errorf("getting dependencies of %q: %s", typ, err)
+		}
+		snap.Providers = append(snap.Providers, SnapshotProvider{
+			Type:      typ,
+			DependsOn: slices.Sorted(maps.Keys(parents)),
+		})
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(p.resources)) {
+		r := p.resources[k]
+		sr := SnapshotResource{
+			Type:      r.Type,
+			ID:        r.ID,
+			Tags:      r.Tags,
+			DependsOn: slices.Sorted(maps.Keys(p.dependsOn[k])),
+			Retained:  p.retained[k],
+		}
+		if !r.CreatedAt.IsZero() {
+			sr.CreatedAt = &r.CreatedAt
+		}
+		snap.Resources = append(snap.Resources, sr)
+	}
+
+	return snap, nil
+}
+
+// load populates the plan from a snapshot instead of discovering
+// resources.
+func (p *Plan) load(snap *Snapshot) error {
+	if snap.Account != p.Settings.Account {
+		return fmt.Errorf("plan is for account %q, but we are running in account %q", snap.Account, p.Settings.Account)
+	}
+	if snap.Region != p.Settings.Region {
+		return fmt.Errorf("plan is for region %q, but we are running in region %q", snap.Region, p.Settings.Region)
+	}
+	if snap.Partition != p.Settings.Partition {
+		return fmt.Errorf("plan is for partition %q, but we are running in partition %q", snap.Partition, p.Settings.Partition)
+	}
+
+	for _, sr := range snap.Resources {
+		r := sr.Resource()
+		isNew, err := p.addOneResource(r)
+		if err != nil {
+			return fmt.Errorf("adding resource %q: %s", r, err)
+		}
+		if !isNew {
+			return fmt.Errorf("duplicate resource in plan: %q", r)
+		}
+		if sr.Retained != "" {
+			p.retained[r.String()] = sr.Retained
+		}
+	}
+
+	for _, sr := range snap.Resources {
+		key := sr.Resource().String()
+		for _, dep := range sr.DependsOn {
+			if _, ok := p.resources[dep]; !ok {
+				return fmt.Errorf("resource %q depends on unknown resource %q", key, dep)
+			}
+			err := p.addDependency(dep, key)
+			if err != nil {
+				return fmt.Errorf("adding dependency on %q from %q: %s", dep, key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+func snapshotTestPlan(rec *recorder) *Plan {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cluster := &testProvider{typ: "Cluster", roots: []resource.Resource{res("Cluster", "c1")}}
+	vpc := &testProvider{
+		typ:  "VPC",
+		deps: []string{"Cluster"},
+		roots: []resource.Resource{{
+			Type:      "VPC",
+			ID:        []string{"v1"},
+			Tags:      map[string]string{"project": "foo"},
+			CreatedAt: created,
+		}},
+		dependents: map[string][]resource.Resource{"v1": {res("Subnet", "s1"), res("Subnet", "s2")}},
+	}
+	subnet := &testProvider{typ: "Subnet"}
+
+	p := newTestPlan(rec, cluster, vpc, subnet)
+	p.Settings = &resource.Settings{Account: "123456789012", Region: "us-east-2", Partition: "aws"}
+	p.Retain = func(r resource.Resource) string {
+		if r.String() == "Subnet/s2" {
+			return "protected"
+		}
+		return ""
+	}
+	return p
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	var rec recorder
+	p := snapshotTestPlan(&rec)
+	snap, err := p.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = snap.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap, read) {
+		t.Errorf("snapshot changed in a round-trip:\n%+v\n%+v", snap, read)
+	}
+
+	// nothing was deleted during discovery; applying the plan deletes
+	// everything but the retained subnet and the VPC it blocks
+	if len(rec.deleted) != 0 {
+		t.Fatalf("expected nothing to be deleted by Discover, got %v", rec.deleted)
+	}
+	err = p.Apply(context.Background(), read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(rec.deleted)), []string{"Cluster/c1", "Subnet/s1"}) {
+		t.Errorf("expected Cluster/c1 and Subnet/s1 to be deleted, got %v", rec.deleted)
+	}
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	tests := []struct {
+		plan string
+		want string
+	}{
+		{`{"version": 2}`, "unsupported plan version 2"},
+		{`{"version": 1, "surprise": true}`, `unknown field "surprise"`},
+		{`{"version": 1,`, "decoding plan"},
+	}
+	for _, tt := range tests {
+		_, err := ReadSnapshot(strings.NewReader(tt.plan))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ReadSnapshot(%s): got error %v, want %q", tt.plan, err, tt.want)
+		}
+	}
+}
+
+func TestApplySnapshotMismatch(t *testing.T) {
+	tests := []struct {
+		settings resource.Settings
+		want     string
+	}{
+		{resource.Settings{Account: "210987654321", Region: "us-east-2", Partition: "aws"}, `plan is for account "123456789012"`},
+		{resource.Settings{Account: "123456789012", Region: "us-west-2", Partition: "aws"}, `plan is for region "us-east-2"`},
+		{resource.Settings{Account: "123456789012", Region: "us-east-2", Partition: "aws-cn"}, `plan is for partition "aws"`},
+	}
+	for _, tt := range tests {
+		var rec recorder
+		p := snapshotTestPlan(&rec)
+		snap, err := p.Discover(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.Settings = &tt.settings
+		err = p.Apply(context.Background(), snap)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got error %v, want %q", err, tt.want)
+		}
+		if len(rec.deleted) != 0 {
+			t.Errorf("expected nothing to be deleted, got %v", rec.deleted)
+		}
+	}
+}
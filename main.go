@@ -5,14 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/aslatter/aws-project-scrub/internal/filter"
 	"github.com/aslatter/aws-project-scrub/internal/resource"
 	"github.com/aslatter/aws-project-scrub/internal/schedule"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"golang.org/x/sys/unix"
 )
@@ -33,114 +38,535 @@ func mainErr() error {
 		return err
 	}
 
+	prot, err := loadProtection(c)
+	if err != nil {
+		return err
+	}
+
+	guard, err := loadGuardrails(c)
+	if err != nil {
+		return err
+	}
+
+	base, err := loadAWSConfig(ctx, c)
+	if err != nil {
+		return err
+	}
+	limits := resource.DefaultRateLimits().Merge(resource.RateLimits(c.rateLimits))
+	guard.org = regionConfig(base, base.Region, limits)
+
+	err = checkTypePatterns(c, resource.GetAllResourceProviders(&resource.Settings{}))
+	if err != nil {
+		return err
+	}
+
+	if c.assumeRole != "" {
+		return scrubAccounts(ctx, c, prot, guard, base, limits)
+	}
+
 	// validate the passed-in account
-	ac, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(c.region))
+	pl, err := newPlanner(ctx, c, prot, guard, base, limits, c.account)
+	if err != nil {
+		return err
+	}
+
+	if c.journal != "" {
+		pl.journal, err = schedule.OpenJournal(c.journal, c.resume)
+		if err != nil {
+			return err
+		}
+		for _, w := range pl.journal.Warnings() {
+			log.Printf("warning: %s", w)
+		}
+		defer pl.journal.Close()
+	}
+
+	plans, err := pl.regionPlans(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch c.command {
+	case commandApply:
+		return applyPlan(ctx, c, prot, plans[0].plan)
+	case commandGraph:
+		return writeGraph(ctx, c, plans[0].plan)
+	}
+
+	_, err = run(ctx, c, prot, plans)
+	return err
+}
+
+// run discovers the resources of the plans, and prints them ('plan') or
+// deletes them. It returns the number of resources deleted (or which would
+// be).
+func run(ctx context.Context, c *cfg, prot *protection, plans []*regionPlan) (int, error) {
+	if c.command == commandPlan {
+		snaps, err := discoverAll(ctx, c, nil, plans)
+		if err != nil {
+			return 0, err
+		}
+		for i, rp := range plans {
+			err = writePlan(c, rp, snaps[i])
+			if err != nil {
+				return 0, err
+			}
+		}
+		return countDeletable(snaps), nil
+	}
+
+	// find everything before deleting anything, so we can refuse to
+	// start if foreign or protected resources are in the way.
+	checkProt := prot
+	if c.dryRun {
+		checkProt = nil
+	}
+	snaps, err := discoverAll(ctx, c, checkProt, plans)
 	if err != nil {
-		return fmt.Errorf("loading aws config: %s", err)
+		return 0, err
+	}
+	err = applyAll(ctx, c, plans, snaps)
+	if err != nil {
+		return 0, err
+	}
+	return countDeletable(snaps), nil
+}
+
+// countDeletable counts the resources in discovered plans which aren't
+// retained, or blocked by retained resources.
+func countDeletable(snaps []*schedule.Snapshot) int {
+	n := 0
+	for _, snap := range snaps {
+		blocked := snap.Blocked(func(sr schedule.SnapshotResource) bool {
+			return sr.Retained != ""
+		})
+		for _, sr := range snap.Resources {
+			if sr.Retained == "" && blocked[sr.Resource().String()] == "" {
+				n++
+			}
+		}
 	}
+	return n
+}
+
+// callerIdentity returns the account and partition of the credentials in
+// an AWS config.
+func callerIdentity(ctx context.Context, ac aws.Config) (string, string, error) {
 	stsClient := sts.NewFromConfig(ac)
 	ident, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		return fmt.Errorf("looking up AWS account: %s", err)
+		return "", "", fmt.Errorf("looking up AWS account: %s", err)
 	}
 	if ident.Account == nil {
-		return errors.New("account id unexpectedly nil")
+		return "", "", errors.New("account id unexpectedly nil")
 	}
 	if ident.Arn == nil {
-		return errors.New("caller ARN unexpectedly nil")
-	}
-	if c.account != *ident.Account {
-		return fmt.Errorf("expected account %q, got %q", c.account, *ident.Account)
+		return "", "", errors.New("caller ARN unexpectedly nil")
 	}
 
 	parsedARN, err := arn.Parse(*ident.Arn)
 	if err != nil {
-		return fmt.Errorf("parsing identity ARN: %s", err)
+		return "", "", fmt.Errorf("parsing identity ARN: %s", err)
+	}
+	return *ident.Account, parsedARN.Partition, nil
+}
+
+// newPlanner checks that an AWS config is for the expected account, and
+// that the guardrails allow running against it, and returns a planner for
+// the account.
+func newPlanner(ctx context.Context, c *cfg, prot *protection, guard *guardrails, base aws.Config, limits resource.RateLimits, account string) (*planner, error) {
+	ac := regionConfig(base, base.Region, limits)
+	actual, partition, err := callerIdentity(ctx, ac)
+	if err != nil {
+		return nil, err
+	}
+	if account != actual {
+		return nil, fmt.Errorf("expected account %q, got %q", account, actual)
+	}
+	err = guard.check(ctx, ac, account)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := &planner{
+		c:         c,
+		prot:      prot,
+		base:      base,
+		limits:    limits,
+		account:   account,
+		partition: partition,
+		// resource ages are measured from when we started
+		now: time.Now(),
+	}
+	if c.retryTimeout > 0 {
+		pl.retry = schedule.DefaultRetryPolicy()
+		pl.retry.Timeout = c.retryTimeout
+	}
+	return pl, nil
+}
+
+// regionPlans returns the plans for the -region, or the -regions. Global
+// providers run in the partition's global region (or the -globalRegion).
+func (pl *planner) regionPlans(ctx context.Context) ([]*regionPlan, error) {
+	c := pl.c
+
+	regions := c.regions
+	if len(regions) == 1 && regions[0] == "all" {
+		var err error
+		regions, err = enabledRegions(ctx, regionConfig(pl.base, pl.base.Region, pl.limits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var named []string
+	if len(c.regions) > 0 && c.regions[0] != "all" {
+		named = append(named, c.regions...)
+	}
+	for _, r := range []string{c.region, c.globalRegion} {
+		if r != "" {
+			named = append(named, r)
+		}
+	}
+	if len(named) > 0 {
+		known, err := partitionRegions(ctx, regionConfig(pl.base, pl.base.Region, pl.limits))
+		if err != nil {
+			return nil, err
+		}
+		err = checkRegions(pl.partition, known, named)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	global := c.globalRegion
+	if global == "" {
+		region := c.region
+		if len(regions) > 0 {
+			region = regions[0]
+		}
+		var err error
+		global, err = resource.GlobalRegion(ctx, pl.partition, region)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(regions) == 0 {
+		return []*regionPlan{pl.singleRegionPlan(global)}, nil
+	}
+	return pl.multiRegionPlans(regions, global), nil
+}
+
+// singleRegionPlan returns the plan for the -region. Global providers
+// only take part in the global region, unless -onlyGlobal picks them
+// out explicitly.
+func (pl *planner) singleRegionPlan(global string) *regionPlan {
+	c := pl.c
+	switch {
+	case c.onlyGlobal:
+		return pl.plan(global, "", isGlobal)
+	case c.skipGlobal || c.region != global:
+		if !c.skipGlobal && c.command != commandApply {
+			log.Printf("note: global resources (such as IAM roles) are only handled in %s, or with -onlyGlobal", global)
+		}
+		return pl.plan(c.region, "", func(p resource.ResourceProvider) bool {
+			return !isGlobal(p)
+		})
+	}
+	return pl.plan(c.region, "", func(p resource.ResourceProvider) bool {
+		return true
+	})
+}
+
+// planner builds the plans for an account.
+type planner struct {
+	c         *cfg
+	prot      *protection
+	base      aws.Config
+	limits    resource.RateLimits
+	account   string
+	partition string
+	now       time.Time
+	retry     *schedule.RetryPolicy
+	journal   *schedule.Journal
+	// label output with the account (when scrubbing several)
+	labelAccount bool
+}
+
+// plan builds the plan for a region, with the providers selected by
+// 'include'. Output about the plan is labelled with 'name' (if set), such
+// as the region.
+func (pl *planner) plan(region string, name string, include func(p resource.ResourceProvider) bool) *regionPlan {
+	c := pl.c
+
+	var parts []string
+	if pl.labelAccount {
+		parts = append(parts, pl.account)
+	}
+	if name != "" {
+		parts = append(parts, name)
+	}
+	label := ""
+	if len(parts) != 0 {
+		label = strings.Join(parts, " ") + ": "
 	}
 
 	var s resource.Settings
-	s.AwsConfig = ac
-	s.Partition = parsedARN.Partition
-	s.Region = c.region
-	s.Account = *ident.Account
-	s.Filter.TagKey = c.tagKey
-	s.Filter.TagValue = c.tagValue
+	s.AwsConfig = regionConfig(pl.base, region, pl.limits)
+	s.Partition = pl.partition
+	s.Region = region
+	s.Account = pl.account
+	s.Filter = c.filter
+	if c.namePattern != "" {
+		s.NamePattern = filter.NewGlob(c.namePattern)
+	}
 
 	var rs []resource.ResourceProvider
-	for _, p := range resource.GetAllResourceProviders(&s) {
-		if g, ok := p.(resource.IsGlobal); ok && g.IsGlobal() {
-			if !isGlobalRegion(c.region) {
-				continue
-			}
+	allProviders := resource.GetAllResourceProviders(&s)
+	if c.taggingAPI {
+		s.TaggingAPI = resource.NewTaggingAPI(allProviders)
+	}
+	for _, p := range allProviders {
+		if include(p) {
+			rs = append(rs, p)
 		}
-		rs = append(rs, p)
+	}
+
+	var observer schedule.Observer = schedule.LogObserver{Prefix: label}
+	if c.dryRun {
+		observer = dryRunObserver{schedule.LogObserver{Prefix: label}}
 	}
 
 	plan := schedule.Plan{
 		Providers: rs,
 		Settings:  &s,
 		Filter: func(r resource.Resource) bool {
-			return isResourceOkayToDelete(c, r)
+			return isResourceOkayToDelete(c, r, pl.now)
+		},
+		IncludeType: func(typ string) bool {
+			return isTypeIncluded(c, typ)
+		},
+		Retain: func(r resource.Resource) string {
+			if reason := pl.prot.reason(r); reason != "" {
+				return reason
+			}
+			if c.foreign == foreignSkip || c.foreign == foreignStop {
+				return foreignReason(c, r)
+			}
+			return ""
 		},
+		Retry:     pl.retry,
+		Journal:   pl.journal,
+		Observer:  observer,
+		KeepGoing: c.keepGoing,
+
+		Concurrency:          c.concurrency,
+		TypeConcurrency:      c.typeConcurrency,
+		DiscoveryConcurrency: c.discoveryConcurrency,
+
 		Action: func(ctx context.Context, p resource.ResourceProvider, r resource.Resource) error {
 			if c.dryRun {
-				fmt.Println(r)
+				fmt.Printf("%s%s\n", label, r)
 				return nil
 			}
-			log.Printf("deleting %s ...", r)
 			err := p.DeleteResource(ctx, &s, r)
 			if err != nil {
 				// keep going for not-found errors
 				if resource.IsErrNotFound(err) {
-					log.Printf("warning: %q: %s", r, err)
+					log.Printf("%swarning: %q: %s", label, r, err)
 					return nil
 				}
 
-				// otherwise stop
-				log.Printf("error: %q: %s", r, err)
+				// otherwise fail (the plan retries in-use errors, and
+				// stops unless -keepGoing)
 				return err
 			}
 			return nil
 		},
 	}
 
-	return plan.Exec(ctx)
+	if c.stack != "" {
+		plan.Roots = func(ctx context.Context) ([]resource.Resource, error) {
+			found, skipped, err := resource.FindStackResources(ctx, &s, rs, c.stack)
+			if err != nil {
+				return nil, err
+			}
+			for _, sr := range skipped {
+				log.Printf("warning: not deleting stack resource %s (unsupported type, or global type outside the global region)", sr)
+			}
+			return found, nil
+		}
+	}
+	if c.tfstate != "" {
+		plan.Roots = func(ctx context.Context) ([]resource.Resource, error) {
+			f, err := os.Open(c.tfstate)
+			if err != nil {
+				return nil, fmt.Errorf("opening state file: %s", err)
+			}
+			defer f.Close()
+
+			found, skipped, err := resource.FindTerraformResources(ctx, &s, rs, f)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %s", c.tfstate, err)
+			}
+			for _, sr := range skipped {
+				log.Printf("warning: not deleting %s", sr)
+			}
+			return found, nil
+		}
+	}
+
+	return &regionPlan{region: region, label: label, plan: &plan}
 }
 
-func isResourceOkayToDelete(c *cfg, r resource.Resource) bool {
-	tv, ok := r.Tags[c.tagKey]
-	if !ok {
-		return false
+// writePlan prints the resources in a discovered plan, and optionally
+// saves it for a later 'apply'.
+func writePlan(c *cfg, rp *regionPlan, snap *schedule.Snapshot) error {
+	blocked := snap.Blocked(func(sr schedule.SnapshotResource) bool {
+		return sr.Retained != ""
+	})
+	for _, r := range snap.Resources {
+		switch k := r.Resource().String(); {
+		case r.Retained != "":
+			fmt.Printf("%s%s (not deleted: %s)\n", rp.label, k, r.Retained)
+		case blocked[k] != "":
+			fmt.Printf("%s%s (not deleted: blocked by %s)\n", rp.label, k, blocked[k])
+		default:
+			fmt.Printf("%s%s\n", rp.label, k)
+		}
+	}
+
+	if c.planOut == "" {
+		return nil
+	}
+
+	f, err := os.Create(c.planOut)
+	if err != nil {
+		return fmt.Errorf("creating plan file: %s", err)
+	}
+	err = snap.Write(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("writing plan file: %s", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("writing plan file: %s", err)
+	}
+
+	log.Printf("wrote plan with %d resources to %s", len(snap.Resources), c.planOut)
+	return nil
+}
+
+// writeGraph discovers resources and prints the dependency graph.
+func writeGraph(ctx context.Context, c *cfg, plan *schedule.Plan) error {
+	snap, err := plan.Discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.graphFormat == "mermaid" {
+		return snap.WriteMermaid(os.Stdout)
 	}
-	return tv == c.tagValue
+	return snap.WriteDOT(os.Stdout)
 }
 
-/**
+// applyPlan deletes the resources in a plan-file.
+func applyPlan(ctx context.Context, c *cfg, prot *protection, plan *schedule.Plan) error {
+	f, err := os.Open(c.planFile)
+	if err != nil {
+		return fmt.Errorf("opening plan file: %s", err)
+	}
+	defer f.Close()
+
+	snap, err := schedule.ReadSnapshot(f)
+	if err != nil {
+		return fmt.Errorf("reading %s: %s", c.planFile, err)
+	}
+
+	err = checkProtection(snap, prot)
+	if err != nil {
+		return err
+	}
 
-Global regions:
+	return plan.Apply(ctx, snap)
+}
 
-curl -L "https://raw.githubusercontent.com/boto/botocore/1ad32855c799456250b44c2762cacd67f5647a6e/botocore/data/partitions.json" | \
-	jq -r '.partitions[].outputs.implicitGlobalRegion' | \
-	xargs -n 1 printf "\tcase \"%s\":\n\t\treturn true\n"
+// dryRunObserver logs progress, except for deletions which aren't
+// really happening.
+type dryRunObserver struct {
+	schedule.LogObserver
+}
 
-**/
+func (dryRunObserver) DeletionStarted(r resource.Resource, waited time.Duration) {}
 
-func isGlobalRegion(region string) bool {
-	switch region {
-	case "us-east-1":
-		return true
-	case "cn-northwest-1":
-		return true
-	case "us-gov-west-1":
-		return true
-	case "us-iso-east-1":
-		return true
-	case "us-isob-east-1":
-		return true
-	case "eu-isoe-west-1":
-		return true
-	case "us-isof-south-1":
+// DeletionSkipped lists skipped resources along with the ones we would
+// delete, so it's clear why they're staying.
+func (o dryRunObserver) DeletionSkipped(r resource.Resource, reason string) {
+	fmt.Printf("%s%s (not deleted: %s)\n", o.Prefix, r, reason)
+}
+
+// isTypeIncluded reports if resources of a type may be deleted, according
+// to the -include and -exclude flags.
+func isTypeIncluded(c *cfg, typ string) bool {
+	if len(c.include) > 0 && !c.include.matches(typ) {
+		return false
+	}
+	return !c.exclude.matches(typ)
+}
+
+// checkTypePatterns makes sure every -include and -exclude pattern matches
+// a resource-type, and every -typeConcurrency type is one, so typos don't
+// go unnoticed.
+func checkTypePatterns(c *cfg, providers []resource.ResourceProvider) error {
+	var el []error
+	for _, l := range []struct {
+		flag     string
+		patterns patternList
+	}{{"-include", c.include}, {"-exclude", c.exclude}} {
+		for _, p := range l.patterns {
+			if !slices.ContainsFunc(providers, func(pr resource.ResourceProvider) bool {
+				return patternList{p}.matches(pr.Type())
+			}) {
+				el = append(el, fmt.Errorf("%s pattern %q doesn't match any resource-type", l.flag, p))
+			}
+		}
+	}
+	types := map[string]bool{}
+	for _, pr := range providers {
+		types[pr.Type()] = true
+	}
+	for _, typ := range slices.Sorted(maps.Keys(c.typeConcurrency)) {
+		if types[typ] {
+			continue
+		}
+		err := fmt.Errorf("-typeConcurrency type %q isn't a resource-type", typ)
+		if guess := closestName(typ, types); guess != "" {
+			err = fmt.Errorf("-typeConcurrency type %q isn't a resource-type (did you mean %q?)", typ, guess)
+		}
+		el = append(el, err)
+	}
+	return errors.Join(el...)
+}
+
+func isResourceOkayToDelete(c *cfg, r resource.Resource, now time.Time) bool {
+	if c.filter != nil && !c.filter.Match(r.Tags) {
+		return false
+	}
+
+	if c.olderThan == 0 && c.newerThan == 0 {
 		return true
 	}
-	return false
+	if r.CreatedAt.IsZero() {
+		return c.includeUnknownAge
+	}
+	age := now.Sub(r.CreatedAt)
+	if c.olderThan > 0 && age < c.olderThan {
+		return false
+	}
+	if c.newerThan > 0 && age >= c.newerThan {
+		return false
+	}
+	return true
 }
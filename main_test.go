@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aslatter/aws-project-scrub/internal/filter"
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+)
+
+func TestIsResourceOkayToDelete(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time {
+		return now.Add(-d)
+	}
+
+	tests := []struct {
+		name    string
+		c       cfg
+		created time.Time
+		want    bool
+	}{
+		{"no age limits", cfg{}, ago(time.Minute), true},
+		{"no age limits, unknown age", cfg{}, time.Time{}, true},
+
+		{"older than", cfg{olderThan: 6 * time.Hour}, ago(7 * time.Hour), true},
+		{"exactly olderThan", cfg{olderThan: 6 * time.Hour}, ago(6 * time.Hour), true},
+		{"just under olderThan", cfg{olderThan: 6 * time.Hour}, ago(6*time.Hour - time.Second), false},
+
+		{"newer than", cfg{newerThan: time.Hour}, ago(time.Minute), true},
+		{"exactly newerThan", cfg{newerThan: time.Hour}, ago(time.Hour), false},
+		{"just under newerThan", cfg{newerThan: time.Hour}, ago(time.Hour - time.Second), true},
+
+		{"between", cfg{olderThan: time.Hour, newerThan: 3 * time.Hour}, ago(2 * time.Hour), true},
+		{"between, too new", cfg{olderThan: time.Hour, newerThan: 3 * time.Hour}, ago(time.Minute), false},
+		{"between, too old", cfg{olderThan: time.Hour, newerThan: 3 * time.Hour}, ago(4 * time.Hour), false},
+
+		{"unknown age", cfg{olderThan: time.Hour}, time.Time{}, false},
+		{"unknown age, included", cfg{olderThan: time.Hour, includeUnknownAge: true}, time.Time{}, true},
+		{"unknown age with newerThan, included", cfg{newerThan: time.Hour, includeUnknownAge: true}, time.Time{}, true},
+		{"includeUnknownAge alone", cfg{includeUnknownAge: true}, ago(time.Minute), true},
+	}
+	for _, tt := range tests {
+		r := resource.Resource{Type: "AWS::EC2::VPC", ID: []string{"vpc-1"}, CreatedAt: tt.created}
+		if got := isResourceOkayToDelete(&tt.c, r, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsResourceOkayToDeleteFilter(t *testing.T) {
+	e, err := filter.Parse("project=foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg{filter: e, olderThan: time.Hour, includeUnknownAge: true}
+
+	// the filter applies whatever the age
+	r := resource.Resource{Type: "AWS::EC2::VPC", ID: []string{"vpc-1"}, Tags: map[string]string{"project": "bar"}}
+	if isResourceOkayToDelete(&c, r, time.Now()) {
+		t.Error("expected a resource not matching the filter to be rejected")
+	}
+	r.Tags["project"] = "foo"
+	if !isResourceOkayToDelete(&c, r, time.Now()) {
+		t.Error("expected a matching resource of unknown age to be accepted")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+	"github.com/aslatter/aws-project-scrub/internal/schedule"
+)
+
+// what to do with dependent resources which belong to another project
+const (
+	foreignAllow = "allow"
+	foreignSkip  = "skip"
+	foreignStop  = "stop"
+)
+
+// foreignReason returns why a resource belongs to another project, or the
+// empty string if it doesn't. A resource is foreign if it has a tag which our
+// filter requires to have a literal value, but with a different value.
+// Resources without the tag are not foreign. Glob and regex terms don't name
+// a project, so they aren't checked.
+func foreignReason(c *cfg, r resource.Resource) string {
+	for _, t := range c.filter.RequiredTerms() {
+		if t.Exists || t.Glob {
+			continue
+		}
+		v, ok := r.Tags[t.Key]
+		if !ok || t.Match(r.Tags) {
+			continue
+		}
+		return fmt.Sprintf("foreign resource: tag %s=%s doesn't match %s", t.Key, v, t)
+	}
+	return ""
+}
+
+// checkOwnership reports foreign resources found in a plan. Depending on
+// the -foreign flag they are skipped with a warning, or stop the run.
+func checkOwnership(c *cfg, snap *schedule.Snapshot) error {
+	if c.foreign == foreignAllow {
+		return nil
+	}
+
+	// who found each resource
+	foundBy := map[string][]string{}
+	for _, sr := range snap.Resources {
+		for _, dep := range sr.DependsOn {
+			foundBy[dep] = append(foundBy[dep], sr.Resource().String())
+		}
+	}
+
+	var el []error
+	for _, sr := range snap.Resources {
+		r := sr.Resource()
+		reason := foreignReason(c, r)
+		if reason == "" {
+			continue
+		}
+		if c.foreign == foreignSkip {
+			log.Printf("warning: not deleting %s (or anything which needs it gone first): %s", r, reason)
+			continue
+		}
+		el = append(el, fmt.Errorf("%s, a dependent of %q: %s", r, foundBy[r.String()], reason))
+	}
+	if len(el) == 0 {
+		return nil
+	}
+	return fmt.Errorf("found resources belonging to another project, refusing to continue:\n%w", errors.Join(el...))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aslatter/aws-project-scrub/internal/filter"
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+	"github.com/aslatter/aws-project-scrub/internal/schedule"
+)
+
+func TestForeignReason(t *testing.T) {
+	tests := []struct {
+		filter string
+		tags   map[string]string
+		// substring of the reason, if the resource is foreign
+		want string
+	}{
+		{"project=foo", map[string]string{"project": "foo"}, ""},
+		{"project=foo", map[string]string{"project": "bar"}, "tag project=bar doesn't match project=foo"},
+		{"project=foo AND env=dev", map[string]string{"project": "foo", "env": "prod"}, "tag env=prod doesn't match env=dev"},
+
+		// untagged
+		{"project=foo", nil, ""},
+		{"project=foo", map[string]string{"owner": "someone"}, ""},
+
+		// terms which don't name a project
+		{"project", map[string]string{"project": "bar"}, ""},
+		{"project=foo-*", map[string]string{"project": "bar"}, ""},
+		{"project=~^foo", map[string]string{"project": "bar"}, ""},
+		{"project=foo OR team=a", map[string]string{"project": "bar"}, ""},
+		{"project!=foo", map[string]string{"project": "foo"}, ""},
+	}
+	for _, tt := range tests {
+		expr, err := filter.Parse(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := cfg{filter: expr}
+		r := resource.Resource{Type: "AWS::EC2::Instance", ID: []string{"i-1"}, Tags: tt.tags}
+
+		got := foreignReason(&c, r)
+		switch {
+		case tt.want == "" && got != "":
+			t.Errorf("%q, tags %v: expected no reason, got %q", tt.filter, tt.tags, got)
+		case !strings.Contains(got, tt.want):
+			t.Errorf("%q, tags %v: expected a reason containing %q, got %q", tt.filter, tt.tags, tt.want, got)
+		}
+	}
+}
+
+func TestCheckOwnership(t *testing.T) {
+	expr, err := filter.Parse("project=foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := &schedule.Snapshot{
+		Resources: []schedule.SnapshotResource{
+			{
+				Type:      "AWS::EC2::VPC",
+				ID:        []string{"vpc-1"},
+				Tags:      map[string]string{"project": "foo"},
+				DependsOn: []string{"AWS::EC2::Instance/i-ours", "AWS::EC2::Instance/i-untagged", "AWS::EC2::Instance/i-theirs"},
+			},
+			{Type: "AWS::EC2::Instance", ID: []string{"i-ours"}, Tags: map[string]string{"project": "foo"}},
+			{Type: "AWS::EC2::Instance", ID: []string{"i-untagged"}},
+			{Type: "AWS::EC2::Instance", ID: []string{"i-theirs"}, Tags: map[string]string{"project": "bar"}},
+		},
+	}
+
+	tests := []struct {
+		foreign string
+		// error substrings, if the run should stop
+		wantErr []string
+	}{
+		{foreign: foreignAllow},
+		{foreign: foreignSkip},
+		{
+			foreign: foreignStop,
+			wantErr: []string{
+				`AWS::EC2::Instance/i-theirs, a dependent of ["AWS::EC2::VPC/vpc-1"]: foreign resource: tag project=bar doesn't match project=foo`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.foreign, func(t *testing.T) {
+			c := cfg{filter: expr, foreign: tt.foreign}
+			err := checkOwnership(&c, snap)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected an error containing %q, got:\n%s", want, err)
+				}
+			}
+			for _, ok := range []string{"i-ours", "i-untagged"} {
+				if strings.Contains(err.Error(), ok) {
+					t.Errorf("%s reported as foreign:\n%s", ok, err)
+				}
+			}
+		})
+	}
+}
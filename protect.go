@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+	"github.com/aslatter/aws-project-scrub/internal/schedule"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// protection decides which resources must never be deleted.
+type protection struct {
+	tagKey   string
+	tagValue string
+
+	// file the entries came from, and the entries: ARNs, resource-IDs
+	// or resource-type patterns
+	file    string
+	entries []string
+
+	// entries from -protectId
+	ids []string
+}
+
+func loadProtection(c *cfg) (*protection, error) {
+	var p protection
+	if c.protectTag != "" {
+		p.tagKey, p.tagValue, _ = strings.Cut(c.protectTag, "=")
+	}
+
+	for _, id := range c.protectIDs {
+		if _, err := path.Match(id, ""); err != nil {
+			return nil, fmt.Errorf("flag -protectId: invalid pattern %q", id)
+		}
+		p.ids = append(p.ids, id)
+	}
+
+	if c.protectFile == "" {
+		return &p, nil
+	}
+	p.file = c.protectFile
+
+	f, err := os.Open(c.protectFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening protect file: %s", err)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	line := 0
+	for s.Scan() {
+		line++
+		entry := strings.TrimSpace(s.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q", c.protectFile, line, entry)
+		}
+		p.entries = append(p.entries, entry)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("reading protect file: %s", err)
+	}
+
+	return &p, nil
+}
+
+// reason returns why a resource is protected, or the empty string
+// if it isn't.
+func (p *protection) reason(r resource.Resource) string {
+	if p.tagKey != "" {
+		if v, ok := r.Tags[p.tagKey]; ok && v == p.tagValue {
+			return fmt.Sprintf("protected by tag %s=%s", p.tagKey, p.tagValue)
+		}
+	}
+	for _, e := range p.ids {
+		if protectEntryMatches(e, r) {
+			return fmt.Sprintf("protected by -protectId %q", e)
+		}
+	}
+	for _, e := range p.entries {
+		if protectEntryMatches(e, r) {
+			return fmt.Sprintf("protected by %q in %s", e, p.file)
+		}
+	}
+	return ""
+}
+
+// protectEntryMatches reports if an entry from a protect file matches
+// a resource. Entries may be:
+//
+//   - a resource-type pattern (AWS::IAM::*)
+//   - a resource pattern (AWS::EC2::VPC/vpc-0123*)
+//   - a resource-ID (vpc-0123, or the ARN of an IAM policy)
+//   - an ARN, whose last part is matched against resource-IDs
+//
+// Matching ARNs this way is loose, but can only protect more than
+// was intended, never less.
+func protectEntryMatches(e string, r resource.Resource) bool {
+	if ok, _ := path.Match(e, r.Type); ok {
+		return true
+	}
+	if ok, _ := path.Match(e, r.String()); ok {
+		return true
+	}
+	if slices.Contains(r.ID, e) {
+		return true
+	}
+	if a, err := arn.Parse(e); err == nil {
+		// the resource part is "type/id", "type:id" or just "id"
+		id := a.Resource
+		if i := strings.LastIndexAny(id, "/:"); i >= 0 {
+			id = id[i+1:]
+		}
+		if slices.Contains(r.ID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProtection fails if a protected resource would block the deletion of
+// any other resource in a plan, so we don't delete half of a project.
+func checkProtection(snap *schedule.Snapshot, p *protection) error {
+	reasons := map[string]string{}
+	blocked := snap.Blocked(func(sr schedule.SnapshotResource) bool {
+		r := sr.Resource()
+		reason := p.reason(r)
+		reasons[r.String()] = reason
+		return reason != ""
+	})
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	var el []error
+	for _, sr := range snap.Resources {
+		k := sr.Resource().String()
+		blocker, ok := blocked[k]
+		if !ok {
+			continue
+		}
+		el = append(el, fmt.Errorf("%s is blocked by %s (%s)", k, blocker, reasons[blocker]))
+	}
+	return fmt.Errorf("protected resources block the deletion of planned resources, refusing to start:\n%w", errors.Join(el...))
+}
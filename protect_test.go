@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+	"github.com/aslatter/aws-project-scrub/internal/schedule"
+)
+
+func TestProtectEntryMatches(t *testing.T) {
+	vpc := resource.Resource{Type: "AWS::EC2::VPC", ID: []string{"vpc-0123"}}
+	policy := resource.Resource{Type: "AWS::IAM::ManagedPolicy", ID: []string{"arn:aws:iam::123456789012:policy/keep"}}
+	nodegroup := resource.Resource{Type: "AWS::EKS::Nodegroup", ID: []string{"cluster-1", "ng-1"}}
+
+	tests := []struct {
+		entry string
+		r     resource.Resource
+		want  bool
+	}{
+		// resource-type patterns
+		{"AWS::EC2::VPC", vpc, true},
+		{"AWS::EC2::*", vpc, true},
+		{"AWS::IAM::*", vpc, false},
+
+		// resource patterns
+		{"AWS::EC2::VPC/vpc-0123", vpc, true},
+		{"AWS::EC2::VPC/vpc-01*", vpc, true},
+		{"AWS::EC2::VPC/vpc-9*", vpc, false},
+		{"AWS::EKS::Nodegroup/cluster-1/*", nodegroup, true},
+
+		// resource-IDs
+		{"vpc-0123", vpc, true},
+		{"vpc-01", vpc, false},
+		{"ng-1", nodegroup, true},
+		{"arn:aws:iam::123456789012:policy/keep", policy, true},
+
+		// ARNs, by their last part
+		{"arn:aws:ec2:us-east-2:123456789012:vpc/vpc-0123", vpc, true},
+		{"arn:aws:ec2:us-east-2:123456789012:vpc/vpc-4567", vpc, false},
+		{"arn:aws:eks:us-east-2:123456789012:nodegroup/cluster-1/ng-1/0a1b", nodegroup, false},
+		{"arn:aws:logs:us-east-2:123456789012:log-group:ng-1", nodegroup, true},
+		{"arn:aws:s3:::vpc-0123", vpc, true},
+	}
+	for _, tt := range tests {
+		if got := protectEntryMatches(tt.entry, tt.r); got != tt.want {
+			t.Errorf("protectEntryMatches(%q, %s): got %v, want %v", tt.entry, tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestProtectionReason(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "protect.txt")
+	err := os.WriteFile(file, []byte("# shared network\n\nAWS::EC2::VPC/vpc-shared*\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadProtection(&cfg{
+		protectTag:  "keep=yes",
+		protectIDs:  []string{"sg-keep"},
+		protectFile: file,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		r    resource.Resource
+		want string
+	}{
+		{resource.Resource{Type: "AWS::EC2::Subnet", ID: []string{"subnet-1"}, Tags: map[string]string{"keep": "yes"}}, "protected by tag keep=yes"},
+		{resource.Resource{Type: "AWS::EC2::Subnet", ID: []string{"subnet-1"}, Tags: map[string]string{"keep": "no"}}, ""},
+		{resource.Resource{Type: "AWS::EC2::SecurityGroup", ID: []string{"sg-keep"}}, `protected by -protectId "sg-keep"`},
+		{resource.Resource{Type: "AWS::EC2::VPC", ID: []string{"vpc-shared-1"}}, `protected by "AWS::EC2::VPC/vpc-shared*" in ` + file},
+		{resource.Resource{Type: "AWS::EC2::VPC", ID: []string{"vpc-1"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := p.reason(tt.r); got != tt.want {
+			t.Errorf("%s: got reason %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestCheckProtection(t *testing.T) {
+	p := &protection{ids: []string{"subnet-keep"}}
+
+	tests := []struct {
+		name      string
+		resources []schedule.SnapshotResource
+		// error substrings, if the plan should be refused
+		wantErr []string
+	}{
+		{
+			name: "nothing protected",
+			resources: []schedule.SnapshotResource{
+				{Type: "AWS::EC2::VPC", ID: []string{"vpc-1"}, DependsOn: []string{"AWS::EC2::Subnet/subnet-1"}},
+				{Type: "AWS::EC2::Subnet", ID: []string{"subnet-1"}},
+			},
+		},
+		{
+			name: "protected leaf",
+			resources: []schedule.SnapshotResource{
+				{Type: "AWS::EC2::Subnet", ID: []string{"subnet-keep"}},
+				{Type: "AWS::EC2::Subnet", ID: []string{"subnet-1"}},
+			},
+		},
+		{
+			name: "protected dependent",
+			resources: []schedule.SnapshotResource{
+				{Type: "AWS::EC2::InternetGateway", ID: []string{"igw-1"}, DependsOn: []string{"AWS::EC2::VPC/vpc-1"}},
+				{Type: "AWS::EC2::VPC", ID: []string{"vpc-1"}, DependsOn: []string{"AWS::EC2::Subnet/subnet-keep", "AWS::EC2::Subnet/subnet-1"}},
+				{Type: "AWS::EC2::Subnet", ID: []string{"subnet-keep"}},
+				{Type: "AWS::EC2::Subnet", ID: []string{"subnet-1"}},
+			},
+			wantErr: []string{
+				`AWS::EC2::VPC/vpc-1 is blocked by AWS::EC2::Subnet/subnet-keep (protected by -protectId "subnet-keep")`,
+				`AWS::EC2::InternetGateway/igw-1 is blocked by AWS::EC2::Subnet/subnet-keep`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkProtection(&schedule.Snapshot{Resources: tt.resources}, p)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected an error containing %q, got:\n%s", want, err)
+				}
+			}
+			if strings.Contains(err.Error(), "subnet-1 is blocked") {
+				t.Errorf("unrelated subnet reported as blocked:\n%s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aslatter/aws-project-scrub/internal/resource"
+	"github.com/aslatter/aws-project-scrub/internal/schedule"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"golang.org/x/sync/errgroup"
+)
+
+// regionPlan is the plan for one region. With -regions, the global
+// resource-providers get a plan of their own, which runs after the
+// regional plans are done.
+type regionPlan struct {
+	region string
+	// prepended to output about the plan ("" with a single region
+	// and account)
+	label  string
+	global bool
+	plan   *schedule.Plan
+}
+
+// wrap adds the plan's label to an error.
+func (rp *regionPlan) wrap(err error) error {
+	if err == nil || rp.label == "" {
+		return err
+	}
+	return fmt.Errorf("%s%w", rp.label, err)
+}
+
+// regionConfig returns a copy of an AWS config for a region, with its
+// own rate-limits (AWS throttles each region separately).
+func regionConfig(base aws.Config, region string, limits resource.RateLimits) aws.Config {
+	ac := base.Copy()
+	ac.Region = region
+	// don't share the options' backing array between copies
+	ac.APIOptions = slices.Clone(base.APIOptions)
+	resource.WithRateLimits(&ac, limits)
+	return ac
+}
+
+func isGlobal(p resource.ResourceProvider) bool {
+	g, ok := p.(resource.IsGlobal)
+	return ok && g.IsGlobal()
+}
+
+// multiRegionPlans returns a plan for each region, without the global
+// providers, followed by a plan for the global providers in the global
+// region. -skipGlobal and -onlyGlobal leave out one or the other.
+func (pl *planner) multiRegionPlans(regions []string, global string) []*regionPlan {
+	var plans []*regionPlan
+	if !pl.c.onlyGlobal {
+		for _, region := range slices.Compact(slices.Sorted(slices.Values(regions))) {
+			plans = append(plans, pl.plan(region, region, func(p resource.ResourceProvider) bool {
+				return !isGlobal(p)
+			}))
+		}
+	}
+	if !pl.c.skipGlobal {
+		rp := pl.plan(global, "global", isGlobal)
+		rp.global = true
+		plans = append(plans, rp)
+	}
+	return plans
+}
+
+// enabledRegions lists the regions enabled for the account.
+func enabledRegions(ctx context.Context, ac aws.Config) ([]string, error) {
+	return describeRegions(ctx, ac, &ec2.DescribeRegionsInput{})
+}
+
+// partitionRegions lists the regions of the account's partition, enabled
+// or not.
+func partitionRegions(ctx context.Context, ac aws.Config) ([]string, error) {
+	return describeRegions(ctx, ac, &ec2.DescribeRegionsInput{AllRegions: aws.Bool(true)})
+}
+
+func describeRegions(ctx context.Context, ac aws.Config, input *ec2.DescribeRegionsInput) ([]string, error) {
+	c := ec2.NewFromConfig(ac)
+	result, err := c.DescribeRegions(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("listing regions: %s", err)
+	}
+	var regions []string
+	for _, r := range result.Regions {
+		if r.RegionName != nil {
+			regions = append(regions, *r.RegionName)
+		}
+	}
+	if len(regions) == 0 {
+		return nil, errors.New("no regions found")
+	}
+	return regions, nil
+}
+
+// checkRegions fails if any of the regions isn't one of the partition's.
+// The SDK resolves endpoints for any name shaped like a region, so a typo
+// such as us-east-99 would otherwise only fail once we call it.
+func checkRegions(partition string, known []string, regions []string) error {
+	var el []error
+	for _, r := range regions {
+		if !slices.Contains(known, r) {
+			el = append(el, fmt.Errorf("unknown region %q in partition %s", r, partition))
+		}
+	}
+	return errors.Join(el...)
+}
+
+// discoverAll discovers the resources of every plan, in parallel, and
+// checks them for foreign resources. If 'prot' is set protected resources
+// which block deletions are an error as well.
+func discoverAll(ctx context.Context, c *cfg, prot *protection, plans []*regionPlan) ([]*schedule.Snapshot, error) {
+	snaps := make([]*schedule.Snapshot, len(plans))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, rp := range plans {
+		g.Go(func() error {
+			snap, err := rp.plan.Discover(gctx)
+			if err != nil {
+				return rp.wrap(err)
+			}
+			err = checkOwnership(c, snap)
+			if err != nil {
+				return rp.wrap(err)
+			}
+			if prot != nil {
+				err = checkProtection(snap, prot)
+				if err != nil {
+					return rp.wrap(err)
+				}
+			}
+			snaps[i] = snap
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return snaps, nil
+}
+
+// applyAll deletes the resources of the regional plans in parallel,
+// and then those of the global plan (if any). Global resources (such as
+// IAM roles) may be in use by regional ones, so the global plan doesn't
+// run if any regional plan fails.
+func applyAll(ctx context.Context, c *cfg, plans []*regionPlan, snaps []*schedule.Snapshot) error {
+	var mu sync.Mutex
+	var el []error
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, rp := range plans {
+		if rp.global {
+			continue
+		}
+		g.Go(func() error {
+			err := rp.wrap(rp.plan.Apply(gctx, snaps[i]))
+			if err != nil && c.keepGoing {
+				// let the other regions finish
+				mu.Lock()
+				el = append(el, err)
+				mu.Unlock()
+				return nil
+			}
+			return err
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+	if len(el) != 0 {
+		el = append(el, errors.New("not deleting global resources, as regional deletions failed"))
+		return errors.Join(el...)
+	}
+
+	for i, rp := range plans {
+		if rp.global {
+			return rp.wrap(rp.plan.Apply(ctx, snaps[i]))
+		}
+	}
+	return nil
+}
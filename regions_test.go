@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRegions(t *testing.T) {
+	// as DescribeRegions lists them for each partition
+	known := map[string][]string{
+		"aws":        {"us-east-1", "us-east-2", "us-west-2", "eu-west-1", "ap-southeast-2"},
+		"aws-cn":     {"cn-north-1", "cn-northwest-1"},
+		"aws-us-gov": {"us-gov-east-1", "us-gov-west-1"},
+	}
+
+	tests := []struct {
+		partition string
+		regions   []string
+		// error substrings, if the regions should be refused
+		wantErr []string
+	}{
+		{"aws", []string{"us-east-1", "eu-west-1"}, nil},
+		{"aws-cn", []string{"cn-northwest-1"}, nil},
+		{"aws-us-gov", []string{"us-gov-east-1", "us-gov-west-1"}, nil},
+
+		{"aws", []string{"us-east-99"}, []string{`unknown region "us-east-99" in partition aws`}},
+		{"aws", []string{"us-east-1", "xx-fake-1", "cn-north-1"}, []string{
+			`unknown region "xx-fake-1" in partition aws`,
+			`unknown region "cn-north-1" in partition aws`,
+		}},
+		{"aws-cn", []string{"us-east-1"}, []string{`unknown region "us-east-1" in partition aws-cn`}},
+		{"aws-us-gov", []string{"us-gov-east-2"}, []string{`unknown region "us-gov-east-2" in partition aws-us-gov`}},
+	}
+	for _, tt := range tests {
+		err := checkRegions(tt.partition, known[tt.partition], tt.regions)
+		if len(tt.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s %v: %s", tt.partition, tt.regions, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s %v: expected an error", tt.partition, tt.regions)
+			continue
+		}
+		for _, want := range tt.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s %v: expected an error containing %q, got:\n%s", tt.partition, tt.regions, want, err)
+			}
+		}
+	}
+}